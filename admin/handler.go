@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"simplerick/internal"
+	"simplerick/internal/discord"
+	"strings"
+)
+
+type Handler struct {
+	executor *discord.Executor
+	tracker  discord.Tracker
+	config   internal.AdminConfig
+}
+
+func ProvideHandler(executor *discord.Executor, tracker discord.Tracker, config internal.AdminConfig) Handler {
+	return Handler{
+		executor: executor,
+		tracker:  tracker,
+		config:   config,
+	}
+}
+
+// Register adds the admin routes to the router, unless no admin token is
+// configured.
+func (h Handler) Register(r *mux.Router) {
+	if len(h.config.Token) == 0 {
+		log.Debug().Msg("[Admin] Admin API disabled")
+		return
+	}
+
+	s := r.PathPrefix("/api/v1/admin").Subrouter()
+	s.Use(h.authenticate)
+	s.HandleFunc("/dead-letters", h.listDeadLetters).Methods(http.MethodGet)
+	s.HandleFunc("/dead-letters/{id}", h.getDeadLetter).Methods(http.MethodGet)
+	s.HandleFunc("/dead-letters/{id}", h.discardDeadLetter).Methods(http.MethodDelete)
+	s.HandleFunc("/dead-letters/{id}/replay", h.replayDeadLetter).Methods(http.MethodPost)
+	s.HandleFunc("/tracker", h.trackerStats).Methods(http.MethodGet)
+}
+
+func (h Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), h.config.Token) != 1 {
+			log.Warn().Str("remote", req.RemoteAddr).Msg("[Admin] Rejected unauthenticated call")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (h Handler) listDeadLetters(w http.ResponseWriter, req *http.Request) {
+	letters, err := h.executor.DeadLetters()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, letters)
+}
+
+func (h Handler) getDeadLetter(w http.ResponseWriter, req *http.Request) {
+	letter, err := h.executor.DeadLetter(mux.Vars(req)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, letter)
+}
+
+func (h Handler) replayDeadLetter(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	if err := h.executor.ReplayDeadLetter(id); err != nil {
+		writeError(w, err)
+		return
+	}
+	log.Info().Str("task", id).Msg("[Admin] Replayed dead letter")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h Handler) discardDeadLetter(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	if err := h.executor.DiscardDeadLetter(id); err != nil {
+		writeError(w, err)
+		return
+	}
+	log.Info().Str("task", id).Msg("[Admin] Discarded dead letter")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h Handler) trackerStats(w http.ResponseWriter, req *http.Request) {
+	stats, err := h.tracker.Stats()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("[Admin] Failed to write response")
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	if errors.Is(err, discord.ErrDeadLetterNotFound) {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, discord.ErrQueueFull) {
+		statusCode = http.StatusServiceUnavailable
+	} else {
+		log.Error().Err(err).Msg("[Admin] Failed to handle call")
+	}
+	writeJSON(w, statusCode, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"simplerick/internal"
+	"simplerick/internal/discord"
+	"simplerick/internal/env"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const deadLettersUsage = `Usage: simplerick dead-letters <command> [id]
+
+Commands:
+  list           List all dead letters
+  show <id>      Show a dead letter with its attempt history
+  replay <id>    Enqueue the dead letter again
+  discard <id>   Delete the dead letter
+
+The commands talk to the admin API of a running instance, configured through
+ADMIN_URL and ADMIN_TOKEN. ADMIN_URL defaults to the local address the server
+listens on according to LISTEN_ADDR and TLS_CERT_FILE.
+`
+
+// runDeadLettersCommand handles the dead-letters subcommand and returns the
+// exit code.
+func runDeadLettersCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, deadLettersUsage)
+		return 2
+	}
+
+	serverConfig, err := internal.ProvideServerConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return 1
+	}
+
+	client := adminClient{
+		baseUrl: strings.TrimSuffix(env.GetString("ADMIN_URL", serverConfig.LocalURL()), "/"),
+		token:   env.GetString("ADMIN_TOKEN", ""),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	switch command := args[0]; {
+	case command == "list" && len(args) == 1:
+		err = listDeadLetters(client)
+	case command == "show" && len(args) == 2:
+		err = showDeadLetter(client, args[1])
+	case command == "replay" && len(args) == 2:
+		err = client.do(http.MethodPost, "/dead-letters/"+args[1]+"/replay", nil)
+		if err == nil {
+			fmt.Printf("Replayed %s\n", args[1])
+		}
+	case command == "discard" && len(args) == 2:
+		err = client.do(http.MethodDelete, "/dead-letters/"+args[1], nil)
+		if err == nil {
+			fmt.Printf("Discarded %s\n", args[1])
+		}
+	default:
+		fmt.Fprint(os.Stderr, deadLettersUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func listDeadLetters(client adminClient) error {
+	var letters []discord.DeadLetter
+	if err := client.do(http.MethodGet, "/dead-letters", &letters); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFAILED AT\tATTEMPTS\tSTATUS\tERROR")
+	for _, letter := range letters {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+			letter.ID,
+			letter.FailedAt.Format(time.RFC3339),
+			len(letter.Attempts),
+			formatStatusCode(letter.StatusCode),
+			letter.Error,
+		)
+	}
+	return w.Flush()
+}
+
+func showDeadLetter(client adminClient, id string) error {
+	var letter discord.DeadLetter
+	if err := client.do(http.MethodGet, "/dead-letters/"+id, &letter); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", letter.ID)
+	fmt.Fprintf(w, "Webhook:\t%s\n", letter.URL)
+	if len(letter.Key) > 0 {
+		fmt.Fprintf(w, "Tracking key:\t%s\n", letter.Key)
+	}
+	fmt.Fprintf(w, "Failed at:\t%s\n", letter.FailedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Status:\t%s\n", formatStatusCode(letter.StatusCode))
+	fmt.Fprintf(w, "Error:\t%s\n", letter.Error)
+	if len(letter.ResponseBody) > 0 {
+		fmt.Fprintf(w, "Response:\t%s\n", letter.ResponseBody)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nAttempts:")
+	for _, attempt := range letter.Attempts {
+		fmt.Fprintf(w, "  #%d\t%s\t%s\n", attempt.Attempt, attempt.Time.Format(time.RFC3339), attempt.Error)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	payload, err := json.MarshalIndent(letter.Payload, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nPayload:\n%s\n", payload)
+	return nil
+}
+
+func formatStatusCode(statusCode int) string {
+	if statusCode == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", statusCode)
+}
+
+type adminClient struct {
+	baseUrl string
+	token   string
+	client  *http.Client
+}
+
+// do calls the admin API, decoding the response body into out unless it is
+// nil.
+func (c adminClient) do(method string, path string, out interface{}) error {
+	req, err := http.NewRequest(method, c.baseUrl+"/api/v1/admin"+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to construct request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var body struct {
+			Error string `json:"error"`
+		}
+		data, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		if json.Unmarshal(data, &body) == nil && len(body.Error) > 0 {
+			return fmt.Errorf("%s: %s", res.Status, body.Error)
+		}
+		return fmt.Errorf("received unexpected response from server: %s", res.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
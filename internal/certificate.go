@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"crypto/tls"
+	"github.com/rs/zerolog/log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateCheckInterval is how often the certificate files are checked for
+// changes at most.
+const certificateCheckInterval = 10 * time.Second
+
+// CertificateReloader serves the TLS certificate from disk, loading it again
+// once the files changed, so a renewed certificate is picked up without a
+// restart.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewCertificateReloader(certFile string, keyFile string) (*CertificateReloader, error) {
+	r := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate is meant for tls.Config.GetCertificate.
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < certificateCheckInterval {
+		return r.cert, nil
+	}
+	r.checkedAt = time.Now()
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		log.Error().Err(err).Msg("[Main] Failed to check TLS certificate for changes")
+		return r.cert, nil
+	}
+	if !modTime.After(r.modTime) {
+		return r.cert, nil
+	}
+
+	// Keep serving the old certificate when the new one is broken, e.g. when
+	// only one of the files was replaced yet
+	if err = r.load(modTime); err != nil {
+		log.Error().Err(err).Msg("[Main] Failed to reload TLS certificate")
+		return r.cert, nil
+	}
+	log.Info().Msg("[Main] Reloaded TLS certificate")
+
+	return r.cert, nil
+}
+
+func (r *CertificateReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
+
+func (r *CertificateReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -2,35 +2,270 @@ package internal
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"simplerick/internal/discord"
 	"simplerick/internal/env"
+	"strings"
+	"time"
 )
 
+type DatabaseConfig struct {
+	Path string
+}
+
+type ServerConfig struct {
+	Addr              string
+	TLSCertFile       string
+	TLSKeyFile        string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration
+}
+
+func (c ServerConfig) TLSEnabled() bool {
+	return len(c.TLSCertFile) > 0
+}
+
+// LocalURL returns the url the server is reachable at from the same host.
+func (c ServerConfig) LocalURL() string {
+	scheme := "http"
+	if c.TLSEnabled() {
+		scheme = "https"
+	}
+
+	host, port, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return fmt.Sprintf("%s://%s", scheme, c.Addr)
+	}
+	if ip := net.ParseIP(host); len(host) == 0 || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, port))
+}
+
+type TrackerConfig struct {
+	Store      string
+	TTL        time.Duration
+	MaxEntries int
+}
+
+type AdminConfig struct {
+	Token []byte
+}
+
 type SentryWebhookConfig struct {
-	Secret           []byte
-	IssuesWebhookUrl string
+	Secret                []byte
+	IssuesWebhookUrl      string
+	AlertsWebhookUrl      string
+	EventAlertsWebhookUrl string
+	AdminWebhookUrl       string
+	BaseUrl               string
+	OrgSlug               string
+	// RequireInstallation rejects payloads of installations that were not
+	// recorded through an installation event
+	RequireInstallation bool
+	// Installations are uuids of installations that are known without an
+	// installation event, e.g. the ones installed before they were recorded
+	Installations []string
 }
 
 type GithubWebhookConfig struct {
 	Secret              []byte
+	ApiToken            string
 	ChangelogWebhookUrl string
 	ReleasesWebhookUrl  string
+	IssuesWebhookUrl    string
+	CIWebhookUrl        string
+	TagsWebhookUrl      string
+	PushCommitLimit     int
+}
+
+func ProvideDatabaseConfig() DatabaseConfig {
+	return DatabaseConfig{
+		Path: env.GetString("DATABASE_PATH", filepath.Join("data", "simplerick.db")),
+	}
+}
+
+func ProvideServerConfig() (ServerConfig, error) {
+	addr := env.GetString("LISTEN_ADDR", ":3000")
+	tlsCertFile := env.GetString("TLS_CERT_FILE", "")
+	tlsKeyFile := env.GetString("TLS_KEY_FILE", "")
+	maxHeaderBytes, err := env.GetInt("SERVER_MAX_HEADER_BYTES", 1<<20)
+	if err != nil {
+		return ServerConfig{}, fmt.Errorf("environment variable SERVER_MAX_HEADER_BYTES is invalid: %w", err)
+	}
+
+	readTimeout, err := getTimeout("SERVER_READ_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	readHeaderTimeout, err := getTimeout("SERVER_READ_HEADER_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	writeTimeout, err := getTimeout("SERVER_WRITE_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	idleTimeout, err := getTimeout("SERVER_IDLE_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	shutdownTimeout, err := getTimeout("SHUTDOWN_TIMEOUT", 8*time.Second)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+
+	if (len(tlsCertFile) == 0) != (len(tlsKeyFile) == 0) {
+		return ServerConfig{}, errors.New("environment variables TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if maxHeaderBytes < 1 {
+		return ServerConfig{}, errors.New("environment variable SERVER_MAX_HEADER_BYTES must be at least 1")
+	}
+
+	return ServerConfig{
+		Addr:              addr,
+		TLSCertFile:       tlsCertFile,
+		TLSKeyFile:        tlsKeyFile,
+		ReadTimeout:       readTimeout,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
+		ShutdownTimeout:   shutdownTimeout,
+	}, nil
+}
+
+func getTimeout(key string, defaultVal time.Duration) (time.Duration, error) {
+	timeout, err := env.GetDuration(key, defaultVal)
+	if err != nil {
+		return 0, fmt.Errorf("environment variable %s is invalid: %w", key, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("environment variable %s must not be negative", key)
+	}
+	return timeout, nil
+}
+
+func ProvideTrackerConfig() (TrackerConfig, error) {
+	store := env.GetString("TRACKER_STORE", "database")
+	ttl, err := env.GetDuration("TRACKER_TTL", 30*24*time.Hour)
+	if err != nil {
+		return TrackerConfig{}, fmt.Errorf("environment variable TRACKER_TTL is invalid: %w", err)
+	}
+	maxEntries, err := env.GetInt("TRACKER_MAX_ENTRIES", 10000)
+	if err != nil {
+		return TrackerConfig{}, fmt.Errorf("environment variable TRACKER_MAX_ENTRIES is invalid: %w", err)
+	}
+
+	if store != "database" && store != "memory" {
+		return TrackerConfig{}, fmt.Errorf("environment variable TRACKER_STORE must be either database or memory, got %s", store)
+	}
+
+	if ttl < 0 {
+		return TrackerConfig{}, errors.New("environment variable TRACKER_TTL must not be negative")
+	}
+
+	if maxEntries < 0 {
+		return TrackerConfig{}, errors.New("environment variable TRACKER_MAX_ENTRIES must not be negative")
+	}
+
+	return TrackerConfig{store, ttl, maxEntries}, nil
+}
+
+func ProvideQueueConfig() (discord.QueueConfig, error) {
+	capacity, err := env.GetInt("DISCORD_QUEUE_CAPACITY", 1000)
+	if err != nil {
+		return discord.QueueConfig{}, fmt.Errorf("environment variable DISCORD_QUEUE_CAPACITY is invalid: %w", err)
+	}
+	overflow := discord.OverflowPolicy(env.GetString("DISCORD_QUEUE_OVERFLOW", string(discord.OverflowDropOldest)))
+
+	if capacity < 0 {
+		return discord.QueueConfig{}, errors.New("environment variable DISCORD_QUEUE_CAPACITY must not be negative")
+	}
+
+	switch overflow {
+	case discord.OverflowDropOldest, discord.OverflowReject, discord.OverflowSpill:
+	default:
+		return discord.QueueConfig{}, fmt.Errorf("environment variable DISCORD_QUEUE_OVERFLOW must be one of drop_oldest, reject or spill, got %s", overflow)
+	}
+
+	if overflow == discord.OverflowDropOldest && capacity == 1 {
+		return discord.QueueConfig{}, errors.New("environment variable DISCORD_QUEUE_CAPACITY must be at least 2 to drop the oldest task")
+	}
+
+	return discord.QueueConfig{Capacity: capacity, Overflow: overflow}, nil
+}
+
+// ProvideAdminConfig reads the token guarding the admin API, which stays
+// disabled while no token is set.
+func ProvideAdminConfig() AdminConfig {
+	return AdminConfig{
+		Token: env.GetBytes("ADMIN_TOKEN", nil),
+	}
 }
 
 func ProvideSentryWebhookConfig() (SentryWebhookConfig, error) {
 	secret := env.GetBytes("SENTRY_WEBHOOK_SECRET", nil)
 	issuesWebhookUrl := env.GetString("SENTRY_ISSUES_WEBHOOK_URL", "")
+	alertsWebhookUrl := env.GetString("SENTRY_ALERTS_WEBHOOK_URL", issuesWebhookUrl)
+	eventAlertsWebhookUrl := env.GetString("SENTRY_EVENT_ALERTS_WEBHOOK_URL", alertsWebhookUrl)
+	adminWebhookUrl := env.GetString("SENTRY_ADMIN_WEBHOOK_URL", "")
+	// Only used when the payload does not link to the issue itself
+	baseUrl := env.GetString("SENTRY_BASE_URL", "https://sentry.io")
+	orgSlug := env.GetString("SENTRY_ORG_SLUG", "")
+	requireInstallation, err := env.GetBool("SENTRY_REQUIRE_INSTALLATION", false)
+	if err != nil {
+		return SentryWebhookConfig{}, fmt.Errorf("environment variable SENTRY_REQUIRE_INSTALLATION is invalid: %w", err)
+	}
+	var installations []string
+	for _, uuid := range strings.Split(env.GetString("SENTRY_INSTALLATIONS", ""), ",") {
+		if uuid = strings.TrimSpace(uuid); len(uuid) > 0 {
+			installations = append(installations, uuid)
+		}
+	}
 
 	if len(issuesWebhookUrl) == 0 {
 		return SentryWebhookConfig{}, errors.New("environment variable SENTRY_ISSUES_WEBHOOK_URL is not set")
 	}
 
-	return SentryWebhookConfig{secret, issuesWebhookUrl}, nil
+	if u, err := url.Parse(baseUrl); err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return SentryWebhookConfig{}, fmt.Errorf("environment variable SENTRY_BASE_URL must be an absolute url, got %s", baseUrl)
+	}
+
+	return SentryWebhookConfig{
+		Secret:                secret,
+		IssuesWebhookUrl:      issuesWebhookUrl,
+		AlertsWebhookUrl:      alertsWebhookUrl,
+		EventAlertsWebhookUrl: eventAlertsWebhookUrl,
+		AdminWebhookUrl:       adminWebhookUrl,
+		BaseUrl:               strings.TrimSuffix(baseUrl, "/"),
+		OrgSlug:               orgSlug,
+		RequireInstallation:   requireInstallation,
+		Installations:         installations,
+	}, nil
 }
 
 func ProvideGithubWebhookConfig() (GithubWebhookConfig, error) {
 	secret := env.GetBytes("GITHUB_WEBHOOK_SECRET", nil)
+	apiToken := env.GetString("GITHUB_API_TOKEN", "")
 	changelogWebhookUrl := env.GetString("GITHUB_CHANGES_WEBHOOK_URL", "")
 	releasesWebhookUrl := env.GetString("GITHUB_RELEASES_WEBHOOK_URL", "")
+	issuesWebhookUrl := env.GetString("GITHUB_ISSUES_WEBHOOK_URL", changelogWebhookUrl)
+	ciWebhookUrl := env.GetString("GITHUB_CI_WEBHOOK_URL", changelogWebhookUrl)
+	tagsChannel := env.GetString("GITHUB_TAGS_CHANNEL", "changelog")
+	pushCommitLimit, err := env.GetInt("GITHUB_PUSH_COMMIT_LIMIT", 25)
+	if err != nil {
+		return GithubWebhookConfig{}, fmt.Errorf("environment variable GITHUB_PUSH_COMMIT_LIMIT is invalid: %w", err)
+	}
 
 	if len(changelogWebhookUrl) == 0 {
 		return GithubWebhookConfig{}, errors.New("environment variable GITHUB_CHANGES_WEBHOOK_URL is not set")
@@ -40,5 +275,28 @@ func ProvideGithubWebhookConfig() (GithubWebhookConfig, error) {
 		return GithubWebhookConfig{}, errors.New("environment variable GITHUB_RELEASES_WEBHOOK_URL is not set")
 	}
 
-	return GithubWebhookConfig{secret, changelogWebhookUrl, releasesWebhookUrl}, nil
+	if pushCommitLimit < 1 {
+		return GithubWebhookConfig{}, errors.New("environment variable GITHUB_PUSH_COMMIT_LIMIT must be at least 1")
+	}
+
+	var tagsWebhookUrl string
+	switch tagsChannel {
+	case "changelog":
+		tagsWebhookUrl = changelogWebhookUrl
+	case "releases":
+		tagsWebhookUrl = releasesWebhookUrl
+	default:
+		return GithubWebhookConfig{}, fmt.Errorf("environment variable GITHUB_TAGS_CHANNEL must be either changelog or releases, got %s", tagsChannel)
+	}
+
+	return GithubWebhookConfig{
+		Secret:              secret,
+		ApiToken:            apiToken,
+		ChangelogWebhookUrl: changelogWebhookUrl,
+		ReleasesWebhookUrl:  releasesWebhookUrl,
+		IssuesWebhookUrl:    issuesWebhookUrl,
+		CIWebhookUrl:        ciWebhookUrl,
+		TagsWebhookUrl:      tagsWebhookUrl,
+		PushCommitLimit:     pushCommitLimit,
+	}, nil
 }
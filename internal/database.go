@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"github.com/rs/zerolog/log"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func ProvideDatabase(config DatabaseConfig) (*bbolt.DB, func(), error) {
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+		return nil, nil, err
+	}
+
+	db, err := bbolt.Open(config.Path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		if err := db.Close(); err != nil {
+			log.Error().Err(err).Msg("[Main] Failed to close database")
+		}
+	}
+
+	return db, cleanup, nil
+}
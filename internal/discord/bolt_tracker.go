@@ -0,0 +1,156 @@
+package discord
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"go.etcd.io/bbolt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+var trackerBucket = []byte("tracker")
+
+// NewBoltTracker returns a Tracker that keeps the message ids in the database,
+// so edits keep working across restarts. Message ids are forgotten after the
+// ttl and the oldest ones are dropped once it holds more than maxEntries. Zero
+// disables either limit.
+func NewBoltTracker(db *bbolt.DB, ttl time.Duration, maxEntries int) (*BoltTracker, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(trackerBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltTracker{db: db, ttl: ttl, maxEntries: maxEntries}, nil
+}
+
+type BoltTracker struct {
+	// Accessed atomically, kept first for 64-bit alignment
+	expiredEvictions  uint64
+	capacityEvictions uint64
+
+	db         *bbolt.DB
+	ttl        time.Duration
+	maxEntries int
+}
+
+func (t *BoltTracker) TrackMessageID(key string, id string) {
+	data, err := json.Marshal(trackedMessage{ID: id, TrackedAt: time.Now()})
+	if err == nil {
+		err = t.db.Update(func(tx *bbolt.Tx) error {
+			return tx.Bucket(trackerBucket).Put([]byte(key), data)
+		})
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("[Discord] Failed to track message")
+	}
+}
+
+func (t *BoltTracker) GetMessageID(key string) (string, bool) {
+	var msg trackedMessage
+	var found bool
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(trackerBucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &msg)
+	})
+
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("[Discord] Failed to look up tracked message")
+		return "", false
+	}
+	if !found || msg.expired(t.ttl) {
+		return "", false
+	}
+	return msg.ID, true
+}
+
+func (t *BoltTracker) ForgetMessageID(key string) {
+	err := t.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(trackerBucket).Delete([]byte(key))
+	})
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("[Discord] Failed to forget tracked message")
+	}
+}
+
+// Evict removes the message ids that are older than the ttl. Past the maximum
+// amount of entries the oldest tracked message ids are removed, as looking one
+// up does not record its use in the database.
+func (t *BoltTracker) Evict() (int, error) {
+	var expired, exceeding int
+
+	err := t.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(trackerBucket)
+
+		type entry struct {
+			key       []byte
+			trackedAt time.Time
+		}
+		var live []entry
+		var stale [][]byte
+
+		err := bucket.ForEach(func(k, v []byte) error {
+			key := append([]byte(nil), k...)
+
+			var msg trackedMessage
+			if err := json.Unmarshal(v, &msg); err != nil || msg.expired(t.ttl) {
+				stale = append(stale, key)
+			} else {
+				live = append(live, entry{key, msg.TrackedAt})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		expired = len(stale)
+
+		if t.maxEntries > 0 && len(live) > t.maxEntries {
+			sort.Slice(live, func(i, j int) bool {
+				return live[i].trackedAt.Before(live[j].trackedAt)
+			})
+			exceeding = len(live) - t.maxEntries
+			for _, e := range live[:exceeding] {
+				stale = append(stale, e.key)
+			}
+		}
+
+		for _, key := range stale {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	atomic.AddUint64(&t.expiredEvictions, uint64(expired))
+	atomic.AddUint64(&t.capacityEvictions, uint64(exceeding))
+	return expired + exceeding, nil
+}
+
+func (t *BoltTracker) Stats() (TrackerStats, error) {
+	stats := TrackerStats{
+		MaxEntries:        t.maxEntries,
+		ExpiredEvictions:  atomic.LoadUint64(&t.expiredEvictions),
+		CapacityEvictions: atomic.LoadUint64(&t.capacityEvictions),
+	}
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		stats.Entries = tx.Bucket(trackerBucket).Stats().KeyN
+		return nil
+	})
+
+	return stats, err
+}
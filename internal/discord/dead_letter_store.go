@@ -0,0 +1,128 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"go.etcd.io/bbolt"
+	"sort"
+	"time"
+)
+
+var (
+	deadLetterBucket = []byte("dead_letters")
+
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+)
+
+type DeadLetterAttempt struct {
+	Attempt int       `json:"attempt"`
+	Time    time.Time `json:"time"`
+	Error   string    `json:"error"`
+}
+
+// DeadLetter is a task the executor gave up on, kept around so it can be
+// inspected and replayed.
+type DeadLetter struct {
+	ID           string              `json:"id"`
+	URL          string              `json:"url"`
+	Key          string              `json:"key,omitempty"`
+	Payload      WebhookPayload      `json:"payload"`
+	Error        string              `json:"error"`
+	StatusCode   int                 `json:"status_code,omitempty"`
+	ResponseBody string              `json:"response_body,omitempty"`
+	Attempts     []DeadLetterAttempt `json:"attempts"`
+	FailedAt     time.Time           `json:"failed_at"`
+}
+
+type deadLetterStore struct {
+	db *bbolt.DB
+}
+
+func newDeadLetterStore(db *bbolt.DB) (*deadLetterStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(deadLetterBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &deadLetterStore{db}, nil
+}
+
+// newDeadLetter records why the task of the queue of the url failed.
+func newDeadLetter(url string, task *executorTask, err error) DeadLetter {
+	letter := DeadLetter{
+		ID:       task.id.String(),
+		URL:      url,
+		Key:      task.key,
+		Payload:  task.payload,
+		Error:    err.Error(),
+		Attempts: task.history,
+		FailedAt: time.Now(),
+	}
+
+	var responseErr *responseError
+	if errors.As(err, &responseErr) {
+		letter.StatusCode = responseErr.statusCode
+		letter.ResponseBody = responseErr.body
+	}
+
+	return letter
+}
+
+// putDeadLetter stores the letter within the transaction, so it can be moved
+// out of the queue atomically.
+func putDeadLetter(tx *bbolt.Tx, letter DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(deadLetterBucket).Put([]byte(letter.ID), data)
+}
+
+func (s *deadLetterStore) get(id string) (DeadLetter, error) {
+	var letter DeadLetter
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(deadLetterBucket).Get([]byte(id))
+		if data == nil {
+			return ErrDeadLetterNotFound
+		}
+		return json.Unmarshal(data, &letter)
+	})
+
+	return letter, err
+}
+
+func (s *deadLetterStore) list() ([]DeadLetter, error) {
+	letters := []DeadLetter{}
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(deadLetterBucket).ForEach(func(k, v []byte) error {
+			var letter DeadLetter
+			if err := json.Unmarshal(v, &letter); err != nil {
+				return err
+			}
+			letters = append(letters, letter)
+			return nil
+		})
+	})
+
+	sort.Slice(letters, func(i, j int) bool {
+		return letters[i].FailedAt.Before(letters[j].FailedAt)
+	})
+
+	return letters, err
+}
+
+func (s *deadLetterStore) remove(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(deadLetterBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return ErrDeadLetterNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
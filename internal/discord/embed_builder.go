@@ -3,11 +3,18 @@ package discord
 import "time"
 
 type EmbedBuilder struct {
-	embed Embed
+	embed        Embed
+	autoTruncate bool
 }
 
-func NewEmbedBuilder() *EmbedBuilder {
-	return &EmbedBuilder{}
+func NewEmbedBuilder(opts ...EmbedBuilderOption) *EmbedBuilder {
+	builder := &EmbedBuilder{}
+
+	for _, opt := range opts {
+		opt(builder)
+	}
+
+	return builder
 }
 
 func (e *EmbedBuilder) SetTitle(title string) *EmbedBuilder {
@@ -126,6 +133,27 @@ func (e *EmbedBuilder) SetFooter(text string, opts ...EmbedFooterOption) *EmbedB
 	return e
 }
 
-func (e EmbedBuilder) Build() Embed {
-	return e.embed
+func (e EmbedBuilder) Length() int {
+	return e.embed.Length()
+}
+
+func (e EmbedBuilder) FieldCount() int {
+	return len(e.embed.Fields)
+}
+
+// Build returns the embed after validating it against the limits of Discord.
+// When auto truncation is enabled, anything exceeding a limit gets truncated
+// instead.
+func (e EmbedBuilder) Build() (Embed, error) {
+	embed := e.embed
+	if e.autoTruncate {
+		embed = embed.clone()
+		embed.truncate()
+	}
+
+	if err := embed.Validate(); err != nil {
+		return Embed{}, err
+	}
+
+	return embed, nil
 }
@@ -1,5 +1,13 @@
 package discord
 
+type EmbedBuilderOption func(builder *EmbedBuilder)
+
+func WithAutoTruncate() EmbedBuilderOption {
+	return func(builder *EmbedBuilder) {
+		builder.autoTruncate = true
+	}
+}
+
 type EmbedFooterOption func(footer *EmbedFooter)
 
 func WithFooterIcon(iconUrl string) EmbedFooterOption {
@@ -2,14 +2,17 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"go.etcd.io/bbolt"
+	"io"
+	"io/ioutil"
 	"net/http"
-	"strconv"
 	"sync"
 	"time"
 )
@@ -22,12 +25,34 @@ func WithTrackingKey(key string) EnqueueOption {
 	}
 }
 
+const defaultMaxAttempts = 3
+
+// maxRateLimitWait is how long a task may wait for rate limits in total before
+// it is given up on, so a webhook that stays rate limited can not block its
+// queue forever.
+const maxRateLimitWait = 10 * time.Minute
+
+// requestTimeout bounds a single request to Discord, so a hanging connection
+// can not block its queue.
+const requestTimeout = 30 * time.Second
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// unknownMessageCode is the JSON error code Discord responds with when a
+// message does not exist.
+const unknownMessageCode = 10008
+
 type executorTask struct {
 	id          uuid.UUID
+	seq         uint64
 	key         string
 	attempts    int
 	maxAttempts int
 	payload     WebhookPayload
+	history     []DeadLetterAttempt
+	// rateLimited is how long the task waited for rate limits so far, unlike
+	// the attempts it is not stored and starts over after a restart
+	rateLimited time.Duration
 }
 
 func (e executorTask) shouldTrack() bool {
@@ -35,34 +60,71 @@ func (e executorTask) shouldTrack() bool {
 }
 
 type Executor struct {
-	mu     sync.RWMutex
-	queues map[string]executorQueue
+	mu          sync.RWMutex
+	store       *taskStore
+	deadLetters *deadLetterStore
+	tracker     Tracker
+	config      QueueConfig
+	limiter     *rateLimiter
+	queues      map[string]executorQueue
+
+	// draining is closed when shutting down, quit once the queues should stop
+	// even though tasks are left. ctx is cancelled along with quit, aborting
+	// requests in flight.
+	draining chan struct{}
+	quit     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
 }
 
-func ProvideExecutor() *Executor {
-	return &Executor{
-		queues: make(map[string]executorQueue),
+func ProvideExecutor(db *bbolt.DB, tracker Tracker, config QueueConfig) (*Executor, error) {
+	store, err := newTaskStore(db)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (e *Executor) EnqueueEmbed(url string, embed Embed, opts ...EnqueueOption) {
-	e.enqueue(url, WebhookPayload{Embeds: []Embed{embed}}, opts...)
-}
+	deadLetters, err := newDeadLetterStore(db)
+	if err != nil {
+		return nil, err
+	}
 
-func (e *Executor) enqueue(url string, payload WebhookPayload, opts ...EnqueueOption) {
-	e.mu.Lock()
-	queue, ok := e.queues[url]
-	if !ok {
-		queue = newQueue(url, DefaultTracker())
-		e.queues[url] = queue
-		go queue.start()
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Executor{
+		store:       store,
+		deadLetters: deadLetters,
+		tracker:     tracker,
+		config:      config,
+		limiter:     newRateLimiter(),
+		queues:      make(map[string]executorQueue),
+		draining:    make(chan struct{}),
+		quit:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+
+	// Resume the queues that still had pending tasks when we shut down
+	urls, err := store.queues()
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range urls {
+		log.Info().Msgf("[Discord] Resuming pending tasks for %s", url)
+		e.getQueue(url)
 	}
-	e.mu.Unlock()
 
+	return e, nil
+}
+
+func (e *Executor) EnqueueEmbed(url string, embed Embed, opts ...EnqueueOption) error {
+	return e.enqueue(url, WebhookPayload{Embeds: []Embed{embed}}, opts...)
+}
+
+func (e *Executor) enqueue(url string, payload WebhookPayload, opts ...EnqueueOption) error {
 	task := &executorTask{
 		id:          uuid.New(),
 		attempts:    0,
-		maxAttempts: 3,
+		maxAttempts: defaultMaxAttempts,
 		payload:     payload,
 	}
 
@@ -70,28 +132,107 @@ func (e *Executor) enqueue(url string, payload WebhookPayload, opts ...EnqueueOp
 		opt(task)
 	}
 
-	queue.enqueue(task)
+	dropped, err := e.store.append(url, task, e.config)
+	if errors.Is(err, ErrQueueFull) {
+		log.Warn().
+			Str("task", task.id.String()).
+			Str("payload", task.payload.summary()).
+			Msgf("[Discord] Queue for %s is full, rejected task", url)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store task: %w", err)
+	}
+
+	if dropped != nil {
+		log.Warn().
+			Str("task", dropped.id.String()).
+			Msgf("[Discord] Queue for %s is full, moved oldest task to dead letters", url)
+	}
+	e.getQueue(url).notify(task)
+
+	return nil
+}
+
+func (e *Executor) getQueue(url string) executorQueue {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	queue, ok := e.queues[url]
+	if !ok {
+		queue = newQueue(e.ctx, url, e.store, e.tracker, e.limiter, e.draining, e.quit)
+		e.queues[url] = queue
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			queue.start()
+		}()
+	}
+
+	return queue
+}
+
+// Shutdown lets the queues send their pending tasks until they are empty or
+// the context is done. Tasks that are left stay in the store and are resumed
+// on the next start.
+func (e *Executor) Shutdown(ctx context.Context) error {
+	log.Info().Msg("[Discord] Draining queues")
+	close(e.draining)
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		e.cancel()
+		log.Info().Msg("[Discord] Drained all queues")
+		return nil
+	case <-ctx.Done():
+		close(e.quit)
+		e.cancel()
+		<-done
+		log.Warn().Msg("[Discord] Stopped queues before they were drained, pending tasks are resumed on the next start")
+		return ctx.Err()
+	}
 }
 
-func newQueue(url string, tracker *Tracker) executorQueue {
+func newQueue(ctx context.Context, url string, store *taskStore, tracker Tracker, limiter *rateLimiter, draining, quit chan struct{}) executorQueue {
 	return executorQueue{
-		url:     url,
-		queue:   make(chan *executorTask),
-		tracker: tracker,
+		ctx:      ctx,
+		url:      url,
+		store:    store,
+		pending:  make(chan struct{}, 1),
+		draining: draining,
+		quit:     quit,
+		tracker:  tracker,
+		limiter:  limiter,
 	}
 }
 
+// executorQueue sends the tasks of a single webhook one by one. The tasks
+// themselves live in the store, pending only wakes the queue up.
 type executorQueue struct {
-	url     string
-	queue   chan *executorTask
-	tracker *Tracker
+	ctx      context.Context
+	url      string
+	store    *taskStore
+	pending  chan struct{}
+	draining chan struct{}
+	quit     chan struct{}
+	tracker  Tracker
+	limiter  *rateLimiter
 }
 
-func (q executorQueue) enqueue(task *executorTask) {
-	q.queue <- task
+func (q executorQueue) notify(task *executorTask) {
+	select {
+	case q.pending <- struct{}{}:
+	default:
+	}
 	log.Debug().
 		Str("task", task.id.String()).
-		Msgf("[Discord] Enqueued task for %s, has %d pending tasks", q.url, len(q.queue))
+		Msgf("[Discord] Enqueued task for %s", q.url)
 }
 
 func (q executorQueue) start() {
@@ -99,138 +240,285 @@ func (q executorQueue) start() {
 
 	for {
 		select {
-		case task := <-q.queue:
-			q.processTask(task)
+		case <-q.quit:
+			return
+		default:
+		}
+
+		task, err := q.store.next(q.url)
+		if err != nil {
+			log.Error().Err(err).Msgf("[Discord] Failed to read next task for %s", q.url)
+			if !q.sleep(time.Second) {
+				return
+			}
+			continue
+		}
+
+		if task == nil {
+			select {
+			case <-q.pending:
+				continue
+			case <-q.draining:
+				log.Info().Msgf("[Discord] Stopped queue for %s", q.url)
+				return
+			}
+		}
+
+		err = q.processTask(task)
+		if errors.Is(err, errQueueStopped) {
+			// Leave the task in the store, so it is sent after the restart
+			return
+		}
+		if err != nil {
+			// Keep the task queued when its dead letter can not be stored, it
+			// is retried instead of getting lost
+			if err = q.store.moveToDeadLetters(q.url, task, newDeadLetter(q.url, task, err)); err != nil {
+				log.Error().
+					Err(err).
+					Str("task", task.id.String()).
+					Msg("[Discord] Failed to move failed task to dead letters")
+				if !q.sleep(time.Second) {
+					return
+				}
+			}
+			continue
+		}
+
+		if err = q.store.remove(q.url, task); err != nil {
+			log.Error().
+				Err(err).
+				Str("task", task.id.String()).
+				Msg("[Discord] Failed to remove processed task")
 		}
 	}
 }
 
-func (q executorQueue) processTask(task *executorTask) {
-	task.attempts++
+// sleep pauses the queue, returning false when it was stopped meanwhile.
+func (q executorQueue) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	log.Debug().
-		Str("task", task.id.String()).
-		Int("attempt", task.attempts).
-		Msg("[Discord] Started processing task")
-
-	sentry.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "discord",
-		Message:  "Processing task",
-		Data: map[string]interface{}{
-			"id":      task.id,
-			"attempt": task.attempts,
-		},
-		Level: sentry.LevelInfo,
-	})
+	select {
+	case <-timer.C:
+		return true
+	case <-q.quit:
+		return false
+	}
+}
 
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(&task.payload)
-	if err != nil {
-		log.Error().
-			Err(err).
+func (q executorQueue) processTask(task *executorTask) error {
+	for {
+		task.attempts++
+
+		log.Debug().
 			Str("task", task.id.String()).
 			Int("attempt", task.attempts).
-			Msg("[Discord] Failed to encode payload")
-		return
-	}
+			Msg("[Discord] Started processing task")
 
-	var res *http.Response
-	if task.shouldTrack() {
-		if msgId, tracked := q.tracker.GetMessageID(task.key); tracked {
-			req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/messages/%s?wait=true", q.url, msgId), &buf)
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("task", task.id.String()).
-					Int("attempt", task.attempts).
-					Msg("[Discord] Failed to construct patch request")
-				return
-			}
-			req.Header.Set("Content-Type", "application/json")
-			res, err = http.DefaultClient.Do(req)
-			if err != nil {
+		sentry.AddBreadcrumb(&sentry.Breadcrumb{
+			Category: "discord",
+			Message:  "Processing task",
+			Data: map[string]interface{}{
+				"id":      task.id,
+				"attempt": task.attempts,
+			},
+			Level: sentry.LevelInfo,
+		})
+
+		err := q.sendTask(task)
+		if err == nil {
+			log.Debug().
+				Str("task", task.id.String()).
+				Int("attempt", task.attempts).
+				Msg("[Discord] Successfully processed task")
+			return nil
+		}
+
+		if errors.Is(err, errQueueStopped) {
+			return err
+		}
+
+		var rateLimitErr *rateLimitError
+		if errors.As(err, &rateLimitErr) {
+			// Waiting for a rate limit does not count as a failed attempt, the
+			// limiter holds the next attempt back until the limit resets
+			task.attempts--
+			task.rateLimited += rateLimitErr.resetAfter
+			if task.rateLimited > maxRateLimitWait {
+				task.history = append(task.history, DeadLetterAttempt{
+					Attempt: task.attempts + 1,
+					Time:    time.Now(),
+					Error:   err.Error(),
+				})
 				log.Error().
 					Err(err).
 					Str("task", task.id.String()).
-					Int("attempt", task.attempts).
-					Msg("[Discord] Failed to send patch payload")
-				return
+					Str("payload", task.payload.summary()).
+					Msgf("[Discord] Rate limited for more than %s, giving up", maxRateLimitWait)
+				return err
 			}
-			goto processRes
+			log.Warn().
+				Str("task", task.id.String()).
+				Int("attempt", task.attempts).
+				Msgf("[Discord] Got rate limited, retrying in %s", rateLimitErr.resetAfter)
+			continue
 		}
-	}
-	res, err = http.Post(fmt.Sprintf("%s?wait=true", q.url), "application/json", &buf)
-	if err != nil {
-		log.Error().
+
+		task.history = append(task.history, DeadLetterAttempt{
+			Attempt: task.attempts,
+			Time:    time.Now(),
+			Error:   err.Error(),
+		})
+
+		if !isRetryable(err) || task.attempts >= task.maxAttempts {
+			log.Error().
+				Err(err).
+				Str("task", task.id.String()).
+				Int("attempt", task.attempts).
+				Str("payload", task.payload.summary()).
+				Msg("[Discord] Failed to process task, giving up")
+			return err
+		}
+
+		if err := q.store.update(q.url, task); err != nil {
+			log.Error().
+				Err(err).
+				Str("task", task.id.String()).
+				Msg("[Discord] Failed to store attempt of task")
+		}
+
+		backoff := backoffDuration(task.attempts)
+		log.Warn().
 			Err(err).
 			Str("task", task.id.String()).
 			Int("attempt", task.attempts).
-			Msg("[Discord] Failed to send payload")
-		return
+			Msgf("[Discord] Failed to process task, retrying in %s", backoff)
+		if !q.sleep(backoff) {
+			return errQueueStopped
+		}
+	}
+}
+
+// sendTask sends the payload of the task to Discord, editing the tracked
+// message if there is one.
+func (q executorQueue) sendTask(task *executorTask) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&task.payload); err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	method, url := http.MethodPost, fmt.Sprintf("%s?wait=true", q.url)
+	route := method + " " + q.url
+	if task.shouldTrack() {
+		if msgId, tracked := q.tracker.GetMessageID(task.key); tracked {
+			method, url = http.MethodPatch, fmt.Sprintf("%s/messages/%s?wait=true", q.url, msgId)
+			route = method + " " + q.url + "/messages"
+		}
+	}
+
+	waited, ok := q.limiter.wait(route, q.quit)
+	if !ok {
+		return errQueueStopped
+	}
+	if waited > 0 {
+		log.Debug().
+			Str("task", task.id.String()).
+			Msgf("[Discord] Waited %s for rate limit of %s", waited, route)
 	}
 
-processRes:
+	req, err := http.NewRequestWithContext(q.ctx, method, url, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to construct request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		if q.ctx.Err() != nil {
+			return errQueueStopped
+		}
+		return &transportError{err}
+	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusTooManyRequests {
+		return &rateLimitError{resetAfter: q.limiter.limited(q.url, route, res)}
+	}
+	q.limiter.update(q.url, route, res)
+
 	if !isSuccessHttpCode(res.StatusCode) {
-		if res.StatusCode == http.StatusTooManyRequests {
-			resetTime, err := getRateLimitResetTime(res)
-			if err != nil {
-				log.Error().Err(err).Msg("[Discord] Got rate limited and expected rate limit headers to be set")
-				return
-			}
-			resetAfter := resetTime.Sub(time.Now())
-			log.Warn().
-				Str("task", task.id.String()).
-				Int("attempt", task.attempts).
-				Msgf("[Discord] Got rate limited, re-queueing in %s", resetAfter)
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
 
-			if resetAfter > 0 {
-				time.Sleep(resetAfter)
-			}
-			q.enqueue(task)
-		} else {
-			log.Error().
+		// The tracked message was deleted, so send a new one to track instead
+		if method == http.MethodPatch && isUnknownMessage(res.StatusCode, body) {
+			log.Warn().
 				Str("task", task.id.String()).
-				Int("attempt", task.attempts).
-				Msgf("[Discord] Received unexpected response from server: %d", res.StatusCode)
-			return
+				Str("key", task.key).
+				Msg("[Discord] Tracked message is gone, sending a new one")
+			q.tracker.ForgetMessageID(task.key)
+			return q.sendTask(task)
 		}
+
+		return &responseError{statusCode: res.StatusCode, body: string(body)}
 	}
 
 	var msg Message
 	if err = json.NewDecoder(res.Body).Decode(&msg); err != nil {
-		log.Error().
-			Err(err).
-			Str("task", task.id.String()).
-			Int("attempt", task.attempts).
-			Msg("[Discord] Failed to parse response body")
-		return
+		return fmt.Errorf("failed to parse response body: %w", err)
 	}
 	if task.shouldTrack() {
 		q.tracker.TrackMessageID(task.key, msg.ID)
 	}
 
-	log.Debug().
-		Str("task", task.id.String()).
-		Int("attempt", task.attempts).
-		Msg("[Discord] Successfully processed task")
+	return nil
+}
+
+func isSuccessHttpCode(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
 }
 
-func getRateLimitResetTime(res *http.Response) (time.Time, error) {
-	resetTimeStr := res.Header.Get("x-ratelimit-reset")
-	if len(resetTimeStr) == 0 {
-		return time.Time{}, errors.New("expected an x-ratelimit-reset header")
+// isUnknownMessage reports whether Discord responded that the message does not
+// exist (anymore).
+func isUnknownMessage(statusCode int, body []byte) bool {
+	if statusCode != http.StatusNotFound {
+		return false
 	}
 
-	resetTime, err := strconv.ParseInt(resetTimeStr, 10, 64)
+	var res struct {
+		Code int `json:"code"`
+	}
+	return json.Unmarshal(body, &res) == nil && res.Code == unknownMessageCode
+}
+
+// DeadLetters returns the tasks the executor gave up on, oldest first.
+func (e *Executor) DeadLetters() ([]DeadLetter, error) {
+	return e.deadLetters.list()
+}
+
+func (e *Executor) DeadLetter(id string) (DeadLetter, error) {
+	return e.deadLetters.get(id)
+}
+
+// ReplayDeadLetter enqueues the payload of the dead letter again, removing it
+// from the dead letters.
+func (e *Executor) ReplayDeadLetter(id string) error {
+	letter, err := e.deadLetters.get(id)
 	if err != nil {
-		return time.Time{}, err
+		return err
+	}
+
+	var opts []EnqueueOption
+	if len(letter.Key) > 0 {
+		opts = append(opts, WithTrackingKey(letter.Key))
+	}
+
+	if err = e.enqueue(letter.URL, letter.Payload, opts...); err != nil {
+		return err
 	}
 
-	return time.Unix(resetTime, 0), nil
+	return e.deadLetters.remove(id)
 }
 
-func isSuccessHttpCode(statusCode int) bool {
-	return statusCode >= 200 && statusCode < 300
+func (e *Executor) DiscardDeadLetter(id string) error {
+	return e.deadLetters.remove(id)
 }
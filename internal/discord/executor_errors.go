@@ -0,0 +1,78 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	backoffBase = time.Second
+	backoffMax  = 30 * time.Second
+)
+
+// errQueueStopped is returned when the queue was stopped before the task could
+// be sent.
+var errQueueStopped = errors.New("queue stopped")
+
+// transportError is returned when the request could not be sent at all.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return fmt.Sprintf("failed to send payload: %s", e.err)
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// responseError is returned when Discord responded with an unexpected status.
+type responseError struct {
+	statusCode int
+	body       string
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("received unexpected response from server: %d: %s", e.statusCode, e.body)
+}
+
+// rateLimitError is returned when Discord rate limited the request.
+type rateLimitError struct {
+	resetAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, resets after %s", e.resetAfter)
+}
+
+// isRetryable reports whether sending the task again could succeed, which is
+// the case for network errors and server side errors. Rate limits are retried
+// separately as a rateLimitError.
+func isRetryable(err error) bool {
+	var transportErr *transportError
+	if errors.As(err, &transportErr) {
+		return true
+	}
+
+	var responseErr *responseError
+	if errors.As(err, &responseErr) {
+		return responseErr.statusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// backoffDuration returns the exponential backoff for the given attempt with
+// jitter applied, so queues do not retry in lockstep.
+func backoffDuration(attempt int) time.Duration {
+	backoff := backoffBase << uint(attempt-1)
+	if backoff > backoffMax || backoff <= 0 {
+		backoff = backoffMax
+	}
+
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
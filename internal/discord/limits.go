@@ -0,0 +1,162 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"simplerick/internal/utils"
+	"unicode/utf8"
+)
+
+// Limits of an embed as documented by Discord, see
+// https://discord.com/developers/docs/resources/channel#embed-object-embed-limits
+const (
+	MaxEmbedTitleLength       = 256
+	MaxEmbedDescriptionLength = 4096
+	MaxEmbedFields            = 25
+	MaxEmbedFieldNameLength   = 256
+	MaxEmbedFieldValueLength  = 1024
+	MaxEmbedFooterLength      = 2048
+	MaxEmbedAuthorNameLength  = 256
+	MaxEmbedLength            = 6000
+)
+
+var ErrEmbedLimitExceeded = errors.New("embed exceeds discord limits")
+
+// EmbedLimitError describes which part of an embed exceeds its limit.
+type EmbedLimitError struct {
+	Field  string
+	Length int
+	Limit  int
+}
+
+func (e *EmbedLimitError) Error() string {
+	return fmt.Sprintf("embed %s has a length of %d, exceeding the limit of %d", e.Field, e.Length, e.Limit)
+}
+
+func (e *EmbedLimitError) Unwrap() error {
+	return ErrEmbedLimitExceeded
+}
+
+// Length returns the amount of characters that count towards the total
+// length limit of an embed.
+func (e Embed) Length() int {
+	length := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+
+	for _, field := range e.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+
+	if e.Footer != nil {
+		length += utf8.RuneCountInString(e.Footer.Text)
+	}
+
+	if e.Author != nil {
+		length += utf8.RuneCountInString(e.Author.Name)
+	}
+
+	return length
+}
+
+// Validate checks the embed against the limits of Discord, returning an
+// *EmbedLimitError for the first limit exceeded.
+func (e Embed) Validate() error {
+	if err := checkLimit("title", e.Title, MaxEmbedTitleLength); err != nil {
+		return err
+	}
+
+	if err := checkLimit("description", e.Description, MaxEmbedDescriptionLength); err != nil {
+		return err
+	}
+
+	if len(e.Fields) > MaxEmbedFields {
+		return &EmbedLimitError{"fields", len(e.Fields), MaxEmbedFields}
+	}
+
+	for i, field := range e.Fields {
+		if err := checkLimit(fmt.Sprintf("fields[%d].name", i), field.Name, MaxEmbedFieldNameLength); err != nil {
+			return err
+		}
+		if err := checkLimit(fmt.Sprintf("fields[%d].value", i), field.Value, MaxEmbedFieldValueLength); err != nil {
+			return err
+		}
+	}
+
+	if e.Footer != nil {
+		if err := checkLimit("footer", e.Footer.Text, MaxEmbedFooterLength); err != nil {
+			return err
+		}
+	}
+
+	if e.Author != nil {
+		if err := checkLimit("author", e.Author.Name, MaxEmbedAuthorNameLength); err != nil {
+			return err
+		}
+	}
+
+	if length := e.Length(); length > MaxEmbedLength {
+		return &EmbedLimitError{"total", length, MaxEmbedLength}
+	}
+
+	return nil
+}
+
+// clone copies the embed, so truncating it leaves the original untouched.
+func (e Embed) clone() Embed {
+	if e.Footer != nil {
+		footer := *e.Footer
+		e.Footer = &footer
+	}
+
+	if e.Author != nil {
+		author := *e.Author
+		e.Author = &author
+	}
+
+	fields := make([]*EmbedField, len(e.Fields))
+	for i, field := range e.Fields {
+		f := *field
+		fields[i] = &f
+	}
+	e.Fields = fields
+
+	return e
+}
+
+// truncate shortens every part of the embed to its limit. Fields that do not
+// fit within the total length anymore are dropped.
+func (e *Embed) truncate() {
+	e.Title = utils.Ellipsis(e.Title, MaxEmbedTitleLength)
+	e.Description = utils.Ellipsis(e.Description, MaxEmbedDescriptionLength)
+
+	if len(e.Fields) > MaxEmbedFields {
+		e.Fields = e.Fields[:MaxEmbedFields]
+	}
+
+	for _, field := range e.Fields {
+		field.Name = utils.Ellipsis(field.Name, MaxEmbedFieldNameLength)
+		field.Value = utils.Ellipsis(field.Value, MaxEmbedFieldValueLength)
+	}
+
+	if e.Footer != nil {
+		e.Footer.Text = utils.Ellipsis(e.Footer.Text, MaxEmbedFooterLength)
+	}
+
+	if e.Author != nil {
+		e.Author.Name = utils.Ellipsis(e.Author.Name, MaxEmbedAuthorNameLength)
+	}
+
+	for len(e.Fields) > 0 && e.Length() > MaxEmbedLength {
+		e.Fields = e.Fields[:len(e.Fields)-1]
+	}
+
+	if excess := e.Length() - MaxEmbedLength; excess > 0 {
+		e.Description = utils.Ellipsis(e.Description, utf8.RuneCountInString(e.Description)-excess)
+	}
+}
+
+func checkLimit(field, value string, limit int) error {
+	if length := utf8.RuneCountInString(value); length > limit {
+		return &EmbedLimitError{field, length, limit}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func fields(n int, name, value string) []*EmbedField {
+	f := make([]*EmbedField, n)
+	for i := range f {
+		f[i] = &EmbedField{Name: name, Value: value}
+	}
+	return f
+}
+
+func TestEmbedValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		embed Embed
+		field string
+	}{
+		{"empty", Embed{}, ""},
+		{"title at limit", Embed{Title: strings.Repeat("a", MaxEmbedTitleLength)}, ""},
+		{"title counts runes", Embed{Title: strings.Repeat("ä", MaxEmbedTitleLength)}, ""},
+		{"title too long", Embed{Title: strings.Repeat("a", MaxEmbedTitleLength+1)}, "title"},
+		{"description too long", Embed{Description: strings.Repeat("a", MaxEmbedDescriptionLength+1)}, "description"},
+		{"too many fields", Embed{Fields: fields(MaxEmbedFields+1, "n", "v")}, "fields"},
+		{"field name too long", Embed{Fields: fields(1, strings.Repeat("a", MaxEmbedFieldNameLength+1), "v")}, "fields[0].name"},
+		{"field value too long", Embed{Fields: fields(2, "n", strings.Repeat("a", MaxEmbedFieldValueLength+1))}, "fields[0].value"},
+		{"footer too long", Embed{Footer: &EmbedFooter{Text: strings.Repeat("a", MaxEmbedFooterLength+1)}}, "footer"},
+		{"author too long", Embed{Author: &EmbedAuthor{Name: strings.Repeat("a", MaxEmbedAuthorNameLength+1)}}, "author"},
+		{"total too long", Embed{
+			Description: strings.Repeat("a", MaxEmbedDescriptionLength),
+			Fields:      fields(2, "n", strings.Repeat("a", MaxEmbedFieldValueLength)),
+		}, "total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.embed.Validate()
+			if len(tt.field) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			var limitErr *EmbedLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("Validate() = %v, want *EmbedLimitError", err)
+			}
+			if limitErr.Field != tt.field {
+				t.Errorf("Validate() exceeded %s, want %s", limitErr.Field, tt.field)
+			}
+			if !errors.Is(err, ErrEmbedLimitExceeded) {
+				t.Errorf("Validate() = %v, does not wrap ErrEmbedLimitExceeded", err)
+			}
+		})
+	}
+}
+
+func TestEmbedTruncate(t *testing.T) {
+	tests := []struct {
+		name       string
+		embed      Embed
+		wantFields int
+	}{
+		{"within limits", Embed{Title: "title", Fields: fields(2, "n", "v")}, 2},
+		{"long parts", Embed{
+			Title:       strings.Repeat("ä", MaxEmbedTitleLength*2),
+			Description: strings.Repeat("a", MaxEmbedDescriptionLength*2),
+			Footer:      &EmbedFooter{Text: strings.Repeat("a", MaxEmbedFooterLength*2)},
+			Author:      &EmbedAuthor{Name: strings.Repeat("a", MaxEmbedAuthorNameLength*2)},
+		}, 0},
+		{"too many fields", Embed{Fields: fields(MaxEmbedFields*2, "n", "v")}, MaxEmbedFields},
+		{"drops fields past total", Embed{
+			Description: strings.Repeat("a", MaxEmbedDescriptionLength),
+			Fields:      fields(3, "n", strings.Repeat("a", MaxEmbedFieldValueLength)),
+		}, 1},
+		{"shortens description past total", Embed{
+			Title:       strings.Repeat("a", MaxEmbedTitleLength),
+			Description: strings.Repeat("a", MaxEmbedDescriptionLength),
+			Footer:      &EmbedFooter{Text: strings.Repeat("a", MaxEmbedFooterLength)},
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.embed.Length()
+			embed := tt.embed.clone()
+			embed.truncate()
+
+			if err := embed.Validate(); err != nil {
+				t.Fatalf("Validate() after truncate() = %v", err)
+			}
+			if len(embed.Fields) != tt.wantFields {
+				t.Errorf("truncate() kept %d fields, want %d", len(embed.Fields), tt.wantFields)
+			}
+			if tt.embed.Length() != original {
+				t.Errorf("truncate() modified the original embed")
+			}
+			if embed.Length() > MaxEmbedLength {
+				t.Errorf("truncate() left a length of %d", embed.Length())
+			}
+			if !utf8.ValidString(embed.Title) {
+				t.Errorf("truncate() split a rune of the title")
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package discord
 
+import (
+	"fmt"
+	"simplerick/internal/utils"
+	"strings"
+)
+
 type EmbedImage struct {
 	URL    string `json:"url,omitempty"`
 	Height int    `json:"height,omitempty"`
@@ -47,6 +53,20 @@ type WebhookPayload struct {
 	Embeds []Embed `json:"embeds"`
 }
 
+// summary describes the payload in a single line for logging purposes.
+func (p WebhookPayload) summary() string {
+	titles := make([]string, 0, len(p.Embeds))
+	for _, embed := range p.Embeds {
+		title := embed.Title
+		if len(title) == 0 {
+			title = embed.Description
+		}
+		titles = append(titles, utils.Ellipsis(title, 100))
+	}
+
+	return fmt.Sprintf("%d embed(s): %s", len(p.Embeds), strings.Join(titles, "; "))
+}
+
 type MessageAuthor struct {
 	Bot           bool   `json:"bot"`
 	ID            string `json:"id"`
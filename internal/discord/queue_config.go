@@ -0,0 +1,33 @@
+package discord
+
+import "errors"
+
+// ErrQueueFull is returned when a task is enqueued to a full queue with the
+// OverflowReject policy.
+var ErrQueueFull = errors.New("queue is full")
+
+// errDroppedFromQueue is recorded for tasks dropped by the OverflowDropOldest
+// policy, so they end up in the dead letters.
+var errDroppedFromQueue = errors.New("dropped from full queue")
+
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest drops the oldest pending task to make room. The
+	// oldest task may be in flight, so the one after it is dropped and the
+	// capacity has to be at least 2.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowReject refuses the new task with ErrQueueFull.
+	OverflowReject OverflowPolicy = "reject"
+	// OverflowSpill keeps accepting tasks past the capacity, which wait on
+	// disk until the queue catches up. The queue is unbounded, the capacity
+	// only decides when a warning is logged.
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+// QueueConfig bounds the amount of pending tasks per webhook. A capacity of
+// zero, or the OverflowSpill policy, leaves the queues unbounded.
+type QueueConfig struct {
+	Capacity int
+	Overflow OverflowPolicy
+}
@@ -0,0 +1,181 @@
+package discord
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRetryAfter is used when Discord rate limited a request without
+// telling us for how long.
+const defaultRetryAfter = time.Second
+
+type rateLimitBucket struct {
+	remaining int
+	resetAt   time.Time
+}
+
+// rateLimiter tracks the rate limit buckets Discord reports, so requests wait
+// for a bucket to reset instead of running into a 429. It is shared by all
+// queues, so a global rate limit pauses every one of them, while buckets are
+// kept per webhook.
+type rateLimiter struct {
+	mu          sync.Mutex
+	globalReset time.Time
+	// routes maps a route to the bucket Discord reported for it, buckets
+	// can be shared by several routes of the same webhook
+	routes  map[string]string
+	buckets map[string]*rateLimitBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		routes:  make(map[string]string),
+		buckets: make(map[string]*rateLimitBucket),
+	}
+}
+
+// wait blocks until a request to the route is allowed to go out, returning how
+// long it waited. Returns false when quit was closed while waiting.
+func (l *rateLimiter) wait(route string, quit <-chan struct{}) (time.Duration, bool) {
+	var waited time.Duration
+	for {
+		delay := l.delay(route)
+		if delay <= 0 {
+			return waited, true
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			waited += delay
+		case <-quit:
+			timer.Stop()
+			return waited, false
+		}
+	}
+}
+
+func (l *rateLimiter) delay(route string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(l.globalReset) {
+		return l.globalReset.Sub(now)
+	}
+
+	bucket, ok := l.buckets[l.bucketId(route)]
+	if !ok || bucket.remaining > 0 || !now.Before(bucket.resetAt) {
+		return 0
+	}
+	return bucket.resetAt.Sub(now)
+}
+
+// update records the rate limit headers of the response to the route of the
+// webhook.
+func (l *rateLimiter) update(webhook, route string, res *http.Response) {
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	resetAfter, ok := parseResetAfter(res.Header)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.setBucket(webhook, route, res.Header.Get("X-RateLimit-Bucket"))
+	l.buckets[l.bucketId(route)] = &rateLimitBucket{
+		remaining: remaining,
+		resetAt:   time.Now().Add(resetAfter),
+	}
+}
+
+// limited records a 429 response to the route of the webhook, returning how
+// long to wait before retrying. Falls back to defaultRetryAfter when Discord did
+// not say.
+func (l *rateLimiter) limited(webhook, route string, res *http.Response) time.Duration {
+	var body struct {
+		RetryAfter *float64 `json:"retry_after"`
+		Global     bool     `json:"global"`
+	}
+	data, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	_ = json.Unmarshal(data, &body)
+
+	global := body.Global || res.Header.Get("X-RateLimit-Global") == "true" ||
+		res.Header.Get("X-RateLimit-Scope") == "global"
+
+	retryAfter, ok := time.Duration(0), false
+	if body.RetryAfter != nil {
+		retryAfter, ok = secondsToDuration(*body.RetryAfter), true
+	}
+	if !ok {
+		if seconds, err := strconv.ParseFloat(res.Header.Get("Retry-After"), 64); err == nil {
+			retryAfter, ok = secondsToDuration(seconds), true
+		}
+	}
+	if !ok {
+		retryAfter, ok = parseResetAfter(res.Header)
+	}
+	if !ok || retryAfter <= 0 {
+		retryAfter = defaultRetryAfter
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	resetAt := time.Now().Add(retryAfter)
+	if global {
+		l.globalReset = resetAt
+	} else {
+		l.setBucket(webhook, route, res.Header.Get("X-RateLimit-Bucket"))
+		l.buckets[l.bucketId(route)] = &rateLimitBucket{remaining: 0, resetAt: resetAt}
+	}
+
+	return retryAfter
+}
+
+// setBucket records the bucket Discord reported for the route. The hash does
+// not include the webhook itself, so the same hash of two webhooks refers to
+// different buckets.
+func (l *rateLimiter) setBucket(webhook, route, hash string) {
+	if len(hash) > 0 {
+		l.routes[route] = hash + " " + webhook
+	}
+}
+
+// bucketId returns the bucket of the route, falling back to the route itself
+// until Discord told us its bucket.
+func (l *rateLimiter) bucketId(route string) string {
+	if id, ok := l.routes[route]; ok {
+		return id
+	}
+	return route
+}
+
+// parseResetAfter reads when the bucket resets, preferring the relative
+// X-RateLimit-Reset-After over the X-RateLimit-Reset timestamp.
+func parseResetAfter(header http.Header) (time.Duration, bool) {
+	if seconds, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset-After"), 64); err == nil {
+		return secondsToDuration(seconds), true
+	}
+
+	if epoch, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset"), 64); err == nil {
+		sec, frac := math.Modf(epoch)
+		return time.Until(time.Unix(int64(sec), int64(frac*1e9))), true
+	}
+
+	return 0, false
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
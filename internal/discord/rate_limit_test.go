@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	webhookA = "https://discord.com/api/webhooks/1/a"
+	webhookB = "https://discord.com/api/webhooks/2/b"
+)
+
+func response(statusCode int, body string, headers map[string]string) *http.Response {
+	res := &http.Response{
+		StatusCode: statusCode,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+	for k, v := range headers {
+		res.Header.Set(k, v)
+	}
+	return res
+}
+
+func route(webhook string) string {
+	return http.MethodPost + " " + webhook
+}
+
+func TestRateLimiterBucketsPerWebhook(t *testing.T) {
+	l := newRateLimiter()
+	headers := map[string]string{
+		"X-RateLimit-Bucket":      "abcd",
+		"X-RateLimit-Remaining":   "0",
+		"X-RateLimit-Reset-After": "5",
+	}
+
+	l.update(webhookA, route(webhookA), response(http.StatusOK, "", headers))
+
+	if d := l.delay(route(webhookA)); d <= 0 || d > 5*time.Second {
+		t.Errorf("delay() of exhausted webhook = %s, want up to 5s", d)
+	}
+	if d := l.delay(route(webhookB)); d != 0 {
+		t.Errorf("delay() of other webhook = %s, want 0", d)
+	}
+
+	// The same hash of another webhook must not overwrite the exhausted bucket
+	headers["X-RateLimit-Remaining"] = "4"
+	l.update(webhookB, route(webhookB), response(http.StatusOK, "", headers))
+
+	if d := l.delay(route(webhookA)); d <= 0 {
+		t.Errorf("delay() of exhausted webhook = %s after other webhook updated, want > 0", d)
+	}
+	if d := l.delay(route(webhookB)); d != 0 {
+		t.Errorf("delay() of other webhook = %s, want 0", d)
+	}
+}
+
+func TestRateLimiterSharesBucketBetweenRoutes(t *testing.T) {
+	l := newRateLimiter()
+	headers := map[string]string{
+		"X-RateLimit-Bucket":      "abcd",
+		"X-RateLimit-Remaining":   "0",
+		"X-RateLimit-Reset-After": "5",
+	}
+	patch := http.MethodPatch + " " + webhookA + "/messages"
+
+	l.update(webhookA, route(webhookA), response(http.StatusOK, "", headers))
+	if d := l.delay(patch); d != 0 {
+		t.Errorf("delay() of unknown route = %s, want 0", d)
+	}
+
+	l.update(webhookA, patch, response(http.StatusOK, "", headers))
+	headers["X-RateLimit-Remaining"] = "3"
+	l.update(webhookA, route(webhookA), response(http.StatusOK, "", headers))
+
+	if d := l.delay(patch); d != 0 {
+		t.Errorf("delay() of route sharing the bucket = %s, want 0", d)
+	}
+}
+
+func TestRateLimiterLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		headers    map[string]string
+		retryAfter time.Duration
+		global     bool
+	}{
+		{"body", `{"retry_after": 1.5}`, nil, 1500 * time.Millisecond, false},
+		{"body global", `{"retry_after": 2, "global": true}`, nil, 2 * time.Second, true},
+		{"retry after header", "", map[string]string{"Retry-After": "3"}, 3 * time.Second, false},
+		{"global scope header", "", map[string]string{"Retry-After": "3", "X-RateLimit-Scope": "global"}, 3 * time.Second, true},
+		{"reset after header", "", map[string]string{"X-RateLimit-Reset-After": "4"}, 4 * time.Second, false},
+		{"body before headers", `{"retry_after": 1}`, map[string]string{"Retry-After": "3"}, time.Second, false},
+		{"nothing", "", nil, defaultRetryAfter, false},
+		{"invalid body", "<html>", nil, defaultRetryAfter, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter()
+
+			got := l.limited(webhookA, route(webhookA), response(http.StatusTooManyRequests, tt.body, tt.headers))
+			if got != tt.retryAfter {
+				t.Errorf("limited() = %s, want %s", got, tt.retryAfter)
+			}
+
+			if d := l.delay(route(webhookA)); d <= 0 {
+				t.Errorf("delay() of limited webhook = %s, want > 0", d)
+			}
+			if d := l.delay(route(webhookB)); (d > 0) != tt.global {
+				t.Errorf("delay() of other webhook = %s, want global limit %t", d, tt.global)
+			}
+		})
+	}
+}
+
+func TestRateLimiterUpdateIgnoresMissingHeaders(t *testing.T) {
+	l := newRateLimiter()
+
+	l.update(webhookA, route(webhookA), response(http.StatusOK, "", map[string]string{"X-RateLimit-Remaining": "0"}))
+	if d := l.delay(route(webhookA)); d != 0 {
+		t.Errorf("delay() = %s without reset header, want 0", d)
+	}
+}
+
+func TestRateLimiterWaitStopsOnQuit(t *testing.T) {
+	l := newRateLimiter()
+	l.limited(webhookA, route(webhookA), response(http.StatusTooManyRequests, `{"retry_after": 60}`, nil))
+
+	quit := make(chan struct{})
+	close(quit)
+	if _, ok := l.wait(route(webhookA), quit); ok {
+		t.Errorf("wait() = true after quit, want false")
+	}
+
+	if waited, ok := l.wait(route(webhookB), quit); !ok || waited != 0 {
+		t.Errorf("wait() of other webhook = %s, %t, want 0, true", waited, ok)
+	}
+}
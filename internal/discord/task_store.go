@@ -0,0 +1,250 @@
+package discord
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	queueBucket = []byte("queue")
+	// queueLengthBucket keeps the amount of tasks per queue, so it does not
+	// have to be counted on every append
+	queueLengthBucket = []byte("queue_lengths")
+)
+
+type persistedTask struct {
+	ID       uuid.UUID           `json:"id"`
+	Key      string              `json:"key,omitempty"`
+	Attempts int                 `json:"attempts"`
+	History  []DeadLetterAttempt `json:"history,omitempty"`
+	Payload  WebhookPayload      `json:"payload"`
+}
+
+// taskStore keeps the pending tasks of every queue on disk, so they survive a
+// restart. Tasks of a queue are kept in the order they were appended in.
+type taskStore struct {
+	db *bbolt.DB
+}
+
+func newTaskStore(db *bbolt.DB) (*taskStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(queueBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(queueLengthBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskStore{db}, nil
+}
+
+// append adds the task to the end of the queue of the url, assigning its
+// sequence number. Once the queue holds more tasks than its capacity, the
+// overflow policy decides what happens. A task dropped to make room is moved
+// to the dead letters and returned.
+func (s *taskStore) append(url string, task *executorTask, config QueueConfig) (*executorTask, error) {
+	var dropped *executorTask
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.Bucket(queueBucket).CreateBucketIfNotExists([]byte(url))
+		if err != nil {
+			return err
+		}
+
+		n := queueLength(tx, url, bucket)
+		if config.Capacity > 0 && n >= config.Capacity {
+			switch config.Overflow {
+			case OverflowReject:
+				return ErrQueueFull
+			case OverflowDropOldest:
+				// The first task may be in flight already, so drop the one after it.
+				// Without one there is nothing to drop, the task is rejected instead
+				c := bucket.Cursor()
+				c.First()
+				k, v := c.Next()
+				if k == nil {
+					return ErrQueueFull
+				}
+				if dropped, err = unmarshalTask(k, v); err != nil {
+					return err
+				}
+				if err = c.Delete(); err != nil {
+					return err
+				}
+				if err = putDeadLetter(tx, newDeadLetter(url, dropped, errDroppedFromQueue)); err != nil {
+					return err
+				}
+				n--
+			case OverflowSpill:
+				if n == config.Capacity {
+					log.Warn().Msgf("[Discord] Queue for %s is over capacity, spilling to disk", url)
+				}
+			}
+		}
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		data, err := marshalTask(task)
+		if err != nil {
+			return err
+		}
+
+		task.seq = seq
+		if err = bucket.Put(seqKey(seq), data); err != nil {
+			return err
+		}
+		return setQueueLength(tx, url, n+1)
+	})
+
+	return dropped, err
+}
+
+// next returns the oldest task in the queue of the url, or nil when the queue
+// is empty.
+func (s *taskStore) next(url string) (*executorTask, error) {
+	var task *executorTask
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(queueBucket).Bucket([]byte(url))
+		if bucket == nil {
+			return nil
+		}
+
+		k, v := bucket.Cursor().First()
+		if k == nil {
+			return nil
+		}
+
+		var err error
+		task, err = unmarshalTask(k, v)
+		return err
+	})
+
+	return task, err
+}
+
+// remove deletes the task from the queue of the url.
+func (s *taskStore) remove(url string, task *executorTask) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return removeTask(tx, url, task)
+	})
+}
+
+// update stores the attempts made on the task so far, so they count after a
+// restart. A task that was removed meanwhile is left alone.
+func (s *taskStore) update(url string, task *executorTask) error {
+	data, err := marshalTask(task)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(queueBucket).Bucket([]byte(url))
+		if bucket == nil || bucket.Get(seqKey(task.seq)) == nil {
+			return nil
+		}
+		return bucket.Put(seqKey(task.seq), data)
+	})
+}
+
+// moveToDeadLetters deletes the failed task from the queue of the url and
+// stores its dead letter in the same transaction, so neither can get lost.
+func (s *taskStore) moveToDeadLetters(url string, task *executorTask, letter DeadLetter) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := removeTask(tx, url, task); err != nil {
+			return err
+		}
+		return putDeadLetter(tx, letter)
+	})
+}
+
+// queues returns the urls that have pending tasks.
+func (s *taskStore) queues() ([]string, error) {
+	var urls []string
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(queueBucket).ForEach(func(k, v []byte) error {
+			// Only nested buckets are expected, which have a nil value
+			if v != nil {
+				return nil
+			}
+			if first, _ := tx.Bucket(queueBucket).Bucket(k).Cursor().First(); first != nil {
+				urls = append(urls, string(k))
+			}
+			return nil
+		})
+	})
+
+	return urls, err
+}
+
+func removeTask(tx *bbolt.Tx, url string, task *executorTask) error {
+	bucket := tx.Bucket(queueBucket).Bucket([]byte(url))
+	if bucket == nil || bucket.Get(seqKey(task.seq)) == nil {
+		return nil
+	}
+	if err := bucket.Delete(seqKey(task.seq)); err != nil {
+		return err
+	}
+	return setQueueLength(tx, url, queueLength(tx, url, bucket)-1)
+}
+
+// queueLength returns the amount of tasks in the queue of the url, counting
+// them once for queues stored before their length was kept.
+func queueLength(tx *bbolt.Tx, url string, bucket *bbolt.Bucket) int {
+	if data := tx.Bucket(queueLengthBucket).Get([]byte(url)); len(data) == 8 {
+		return int(binary.BigEndian.Uint64(data))
+	}
+	return bucket.Stats().KeyN
+}
+
+func setQueueLength(tx *bbolt.Tx, url string, n int) error {
+	if n < 0 {
+		n = 0
+	}
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(n))
+	return tx.Bucket(queueLengthBucket).Put([]byte(url), data)
+}
+
+func marshalTask(task *executorTask) ([]byte, error) {
+	return json.Marshal(persistedTask{
+		ID:       task.id,
+		Key:      task.key,
+		Attempts: task.attempts,
+		History:  task.history,
+		Payload:  task.payload,
+	})
+}
+
+func unmarshalTask(k, v []byte) (*executorTask, error) {
+	var p persistedTask
+	if err := json.Unmarshal(v, &p); err != nil {
+		return nil, err
+	}
+
+	return &executorTask{
+		id:          p.ID,
+		seq:         binary.BigEndian.Uint64(k),
+		key:         p.Key,
+		attempts:    p.Attempts,
+		history:     p.History,
+		maxAttempts: defaultMaxAttempts,
+		payload:     p.Payload,
+	}, nil
+}
+
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
@@ -0,0 +1,282 @@
+package discord
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testQueueUrl = "https://discord.com/api/webhooks/1/a"
+
+func openTestDB(t *testing.T) *bbolt.DB {
+	dir, err := ioutil.TempDir("", "simplerick")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := bbolt.Open(filepath.Join(dir, "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+		os.RemoveAll(dir)
+	})
+	return db
+}
+
+func newTestTaskStore(t *testing.T) (*taskStore, *deadLetterStore) {
+	db := openTestDB(t)
+
+	store, err := newTaskStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadLetters, err := newDeadLetterStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, deadLetters
+}
+
+func newTestTask(content string) *executorTask {
+	return &executorTask{
+		id:          uuid.New(),
+		key:         content,
+		maxAttempts: defaultMaxAttempts,
+		payload:     WebhookPayload{Embeds: []Embed{{Title: content}}},
+	}
+}
+
+func appendTasks(t *testing.T, store *taskStore, config QueueConfig, contents ...string) []*executorTask {
+	tasks := make([]*executorTask, len(contents))
+	for i, content := range contents {
+		tasks[i] = newTestTask(content)
+		if _, err := store.append(testQueueUrl, tasks[i], config); err != nil {
+			t.Fatalf("append(%s) = %v", content, err)
+		}
+	}
+	return tasks
+}
+
+func storedLength(t *testing.T, store *taskStore) int {
+	var n int
+	err := store.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(queueBucket).Bucket([]byte(testQueueUrl))
+		n = queueLength(tx, testQueueUrl, bucket)
+		if actual := bucket.Stats().KeyN; actual != n {
+			t.Errorf("queue length is %d, but %d tasks are stored", n, actual)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestTaskStoreOrder(t *testing.T) {
+	store, _ := newTestTaskStore(t)
+	tasks := appendTasks(t, store, QueueConfig{}, "a", "b", "c")
+
+	for _, want := range tasks {
+		task, err := store.next(testQueueUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task == nil {
+			t.Fatalf("next() = nil, want %s", want.key)
+		}
+		if task.id != want.id || task.key != want.key || task.payload.summary() != want.payload.summary() || task.seq != want.seq {
+			t.Errorf("next() = %+v, want %+v", task, want)
+		}
+
+		// Until it is removed, the same task comes next
+		if again, _ := store.next(testQueueUrl); again.id != task.id {
+			t.Errorf("next() without remove = %s, want %s", again.id, task.id)
+		}
+
+		if err = store.remove(testQueueUrl, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if task, err := store.next(testQueueUrl); task != nil || err != nil {
+		t.Errorf("next() of empty queue = %v, %v, want nil, nil", task, err)
+	}
+	if n := storedLength(t, store); n != 0 {
+		t.Errorf("queue length = %d, want 0", n)
+	}
+}
+
+func TestTaskStoreQueues(t *testing.T) {
+	store, _ := newTestTaskStore(t)
+
+	if urls, err := store.queues(); err != nil || len(urls) != 0 {
+		t.Fatalf("queues() = %v, %v, want none", urls, err)
+	}
+
+	tasks := appendTasks(t, store, QueueConfig{}, "a")
+	if urls, _ := store.queues(); len(urls) != 1 || urls[0] != testQueueUrl {
+		t.Errorf("queues() = %v, want %s", urls, testQueueUrl)
+	}
+
+	// Emptied queues are not resumed
+	if err := store.remove(testQueueUrl, tasks[0]); err != nil {
+		t.Fatal(err)
+	}
+	if urls, _ := store.queues(); len(urls) != 0 {
+		t.Errorf("queues() = %v after removing every task, want none", urls)
+	}
+}
+
+func TestTaskStoreRemoveTwice(t *testing.T) {
+	store, _ := newTestTaskStore(t)
+	tasks := appendTasks(t, store, QueueConfig{}, "a", "b")
+
+	for i := 0; i < 2; i++ {
+		if err := store.remove(testQueueUrl, tasks[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := storedLength(t, store); n != 1 {
+		t.Errorf("queue length = %d after removing the same task twice, want 1", n)
+	}
+}
+
+func TestTaskStoreOverflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []string
+		config  QueueConfig
+		err     error
+		dropped string
+		want    []string
+	}{
+		{"unbounded", []string{"a", "b", "c"}, QueueConfig{Capacity: 0, Overflow: OverflowReject}, nil, "", []string{"a", "b", "c", "d"}},
+		{"below capacity", []string{"a", "b"}, QueueConfig{Capacity: 3, Overflow: OverflowReject}, nil, "", []string{"a", "b", "d"}},
+		{"reject", []string{"a", "b", "c"}, QueueConfig{Capacity: 3, Overflow: OverflowReject}, ErrQueueFull, "", []string{"a", "b", "c"}},
+		{"drop oldest keeps first", []string{"a", "b", "c"}, QueueConfig{Capacity: 3, Overflow: OverflowDropOldest}, nil, "b", []string{"a", "c", "d"}},
+		{"drop oldest without second", []string{"a"}, QueueConfig{Capacity: 1, Overflow: OverflowDropOldest}, ErrQueueFull, "", []string{"a"}},
+		{"spill", []string{"a", "b", "c"}, QueueConfig{Capacity: 3, Overflow: OverflowSpill}, nil, "", []string{"a", "b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, deadLetters := newTestTaskStore(t)
+			appendTasks(t, store, QueueConfig{}, tt.initial...)
+
+			dropped, err := store.append(testQueueUrl, newTestTask("d"), tt.config)
+			if err != tt.err {
+				t.Fatalf("append() = %v, want %v", err, tt.err)
+			}
+
+			if len(tt.dropped) == 0 && dropped != nil {
+				t.Errorf("append() dropped %s, want none", dropped.key)
+			}
+			if len(tt.dropped) > 0 {
+				if dropped == nil || dropped.key != tt.dropped {
+					t.Fatalf("append() dropped %v, want %s", dropped, tt.dropped)
+				}
+				letter, err := deadLetters.get(dropped.id.String())
+				if err != nil {
+					t.Fatalf("dropped task has no dead letter: %v", err)
+				}
+				if letter.URL != testQueueUrl || letter.Error != errDroppedFromQueue.Error() {
+					t.Errorf("dead letter = %+v, want dropped from %s", letter, testQueueUrl)
+				}
+			}
+
+			var got []string
+			for {
+				task, err := store.next(testQueueUrl)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if task == nil {
+					break
+				}
+				got = append(got, task.key)
+				if n := storedLength(t, store); n != len(tt.want)-len(got)+1 {
+					t.Errorf("queue length = %d, want %d", n, len(tt.want)-len(got)+1)
+				}
+				if err = store.remove(testQueueUrl, task); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("queue holds %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("queue holds %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestTaskStoreMoveToDeadLetters(t *testing.T) {
+	store, deadLetters := newTestTaskStore(t)
+	config := QueueConfig{Capacity: 2, Overflow: OverflowReject}
+	tasks := appendTasks(t, store, config, "a", "b")
+
+	letter := newDeadLetter(testQueueUrl, tasks[0], errors.New("failed"))
+	if err := store.moveToDeadLetters(testQueueUrl, tasks[0], letter); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := storedLength(t, store); n != 1 {
+		t.Errorf("queue length = %d, want 1", n)
+	}
+	if task, _ := store.next(testQueueUrl); task == nil || task.id != tasks[1].id {
+		t.Errorf("next() = %v, want %s", task, tasks[1].key)
+	}
+	if _, err := deadLetters.get(tasks[0].id.String()); err != nil {
+		t.Errorf("moved task has no dead letter: %v", err)
+	}
+
+	// The freed up slot can be used again
+	if _, err := store.append(testQueueUrl, newTestTask("c"), config); err != nil {
+		t.Errorf("append() = %v after moving a task out, want nil", err)
+	}
+	if _, err := store.append(testQueueUrl, newTestTask("d"), config); err != ErrQueueFull {
+		t.Errorf("append() = %v to full queue, want %v", err, ErrQueueFull)
+	}
+}
+
+func TestTaskStoreUpdateKeepsAttempts(t *testing.T) {
+	store, _ := newTestTaskStore(t)
+	tasks := appendTasks(t, store, QueueConfig{}, "a")
+
+	tasks[0].attempts = 2
+	tasks[0].history = []DeadLetterAttempt{{Attempt: 1, Error: "first"}, {Attempt: 2, Error: "second"}}
+	if err := store.update(testQueueUrl, tasks[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := store.next(testQueueUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.attempts != 2 || len(task.history) != 2 || task.history[1].Error != "second" {
+		t.Errorf("next() = %d attempts with history %+v, want 2 attempts", task.attempts, task.history)
+	}
+
+	// Updating a removed task must not bring it back
+	if err = store.remove(testQueueUrl, task); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.update(testQueueUrl, task); err != nil {
+		t.Fatal(err)
+	}
+	if task, _ = store.next(testQueueUrl); task != nil {
+		t.Errorf("next() = %s after updating a removed task, want nil", task.key)
+	}
+}
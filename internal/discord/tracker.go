@@ -1,27 +1,148 @@
 package discord
 
-import "sync"
+import (
+	"container/list"
+	"sync"
+	"time"
+)
 
-func DefaultTracker() *Tracker {
-	return &Tracker{
-		messagesIds: make(map[string]string),
+// Tracker remembers which Discord message was sent for a tracking key, so
+// later tasks with the same key edit that message instead of sending a new one.
+type Tracker interface {
+	TrackMessageID(key string, id string)
+	GetMessageID(key string) (string, bool)
+	// ForgetMessageID removes the message id of the key, e.g. once the message
+	// was deleted in Discord.
+	ForgetMessageID(key string)
+	// Evict removes the message ids that are older than the ttl or exceed the
+	// maximum amount of entries, returning how many were removed.
+	Evict() (int, error)
+	Stats() (TrackerStats, error)
+}
+
+type TrackerStats struct {
+	Entries           int    `json:"entries"`
+	MaxEntries        int    `json:"max_entries"`
+	ExpiredEvictions  uint64 `json:"expired_evictions"`
+	CapacityEvictions uint64 `json:"capacity_evictions"`
+}
+
+type trackedMessage struct {
+	ID        string    `json:"id"`
+	TrackedAt time.Time `json:"tracked_at"`
+}
+
+func (m trackedMessage) expired(ttl time.Duration) bool {
+	return ttl > 0 && time.Since(m.TrackedAt) > ttl
+}
+
+// NewMemoryTracker returns a Tracker that keeps the message ids in memory,
+// forgetting them after the ttl and dropping the least recently used ones once
+// it holds more than maxEntries. Zero disables either limit.
+func NewMemoryTracker(ttl time.Duration, maxEntries int) *MemoryTracker {
+	return &MemoryTracker{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    list.New(),
+		messages:   make(map[string]*list.Element),
 	}
 }
 
-type Tracker struct {
-	mu          sync.Mutex
-	messagesIds map[string]string
+type memoryEntry struct {
+	key string
+	msg trackedMessage
 }
 
-func (t *Tracker) TrackMessageID(key string, id string) {
+type MemoryTracker struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	// entries is ordered from most to least recently used
+	entries  *list.List
+	messages map[string]*list.Element
+
+	expiredEvictions  uint64
+	capacityEvictions uint64
+}
+
+func (t *MemoryTracker) TrackMessageID(key string, id string) {
 	t.mu.Lock()
-	t.messagesIds[key] = id
-	t.mu.Unlock()
+	defer t.mu.Unlock()
+
+	msg := trackedMessage{ID: id, TrackedAt: time.Now()}
+	if el, ok := t.messages[key]; ok {
+		el.Value.(*memoryEntry).msg = msg
+		t.entries.MoveToFront(el)
+		return
+	}
+
+	t.messages[key] = t.entries.PushFront(&memoryEntry{key, msg})
+
+	for t.maxEntries > 0 && t.entries.Len() > t.maxEntries {
+		t.remove(t.entries.Back())
+		t.capacityEvictions++
+	}
 }
 
-func (t *Tracker) GetMessageID(key string) (string, bool) {
+func (t *MemoryTracker) GetMessageID(key string) (string, bool) {
 	t.mu.Lock()
-	id, ok := t.messagesIds[key]
-	t.mu.Unlock()
-	return id, ok
+	defer t.mu.Unlock()
+
+	el, ok := t.messages[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if entry.msg.expired(t.ttl) {
+		t.remove(el)
+		t.expiredEvictions++
+		return "", false
+	}
+
+	t.entries.MoveToFront(el)
+	return entry.msg.ID, true
+}
+
+func (t *MemoryTracker) ForgetMessageID(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.messages[key]; ok {
+		t.remove(el)
+	}
+}
+
+func (t *MemoryTracker) Evict() (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	evicted := 0
+	for el := t.entries.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*memoryEntry).msg.expired(t.ttl) {
+			t.remove(el)
+			t.expiredEvictions++
+			evicted++
+		}
+		el = next
+	}
+	return evicted, nil
+}
+
+func (t *MemoryTracker) Stats() (TrackerStats, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return TrackerStats{
+		Entries:           t.entries.Len(),
+		MaxEntries:        t.maxEntries,
+		ExpiredEvictions:  t.expiredEvictions,
+		CapacityEvictions: t.capacityEvictions,
+	}, nil
+}
+
+func (t *MemoryTracker) remove(el *list.Element) {
+	t.entries.Remove(el)
+	delete(t.messages, el.Value.(*memoryEntry).key)
 }
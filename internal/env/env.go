@@ -3,6 +3,8 @@ package env
 import (
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"time"
 )
 
 func init() {
@@ -26,3 +28,35 @@ func GetBytes(key string, defaultVal []byte) []byte {
 
 	return defaultVal
 }
+
+func GetInt(key string, defaultVal int) (int, error) {
+	if value, exists := os.LookupEnv(key); exists {
+		return strconv.Atoi(value)
+	}
+
+	return defaultVal, nil
+}
+
+func GetDuration(key string, defaultVal time.Duration) (time.Duration, error) {
+	if value, exists := os.LookupEnv(key); exists {
+		return time.ParseDuration(value)
+	}
+
+	return defaultVal, nil
+}
+
+func GetFloat(key string, defaultVal float64) (float64, error) {
+	if value, exists := os.LookupEnv(key); exists {
+		return strconv.ParseFloat(value, 64)
+	}
+
+	return defaultVal, nil
+}
+
+func GetBool(key string, defaultVal bool) (bool, error) {
+	if value, exists := os.LookupEnv(key); exists {
+		return strconv.ParseBool(value)
+	}
+
+	return defaultVal, nil
+}
@@ -5,4 +5,16 @@ import (
 	"simplerick/internal/discord"
 )
 
-var Set = wire.NewSet(ProvideGithubWebhookConfig, ProvideSentryWebhookConfig, discord.ProvideExecutor)
+var Set = wire.NewSet(
+	ProvideServerConfig,
+	ProvideDatabaseConfig,
+	ProvideDatabase,
+	ProvideTrackerConfig,
+	ProvideEvictions,
+	ProvideTracker,
+	ProvideQueueConfig,
+	ProvideAdminConfig,
+	ProvideGithubWebhookConfig,
+	ProvideSentryWebhookConfig,
+	discord.ProvideExecutor,
+)
@@ -74,11 +74,11 @@ func (w *SentryWriter) parseLogEvent(data []byte) (*sentry.Event, bool) {
 		Timestamp: now(),
 		Level:     sentryLvl,
 		Logger:    logger,
+		Extra:     make(map[string]interface{}),
 	}
 
 	err = jsonparser.ObjectEach(data, func(key, value []byte, vt jsonparser.ValueType, offset int) error {
 		switch string(key) {
-		// case zerolog.LevelFieldName, zerolog.TimestampFieldName:
 		case zerolog.MessageFieldName:
 			event.Message = bytesToStrUnsafe(value)
 		case zerolog.ErrorFieldName:
@@ -86,6 +86,10 @@ func (w *SentryWriter) parseLogEvent(data []byte) (*sentry.Event, bool) {
 				Value:      bytesToStrUnsafe(value),
 				Stacktrace: newStacktrace(),
 			})
+		case zerolog.LevelFieldName, zerolog.TimestampFieldName:
+		default:
+			// Keep context like task ids around, copied as the buffer gets reused
+			event.Extra[string(key)] = string(value)
 		}
 
 		return nil
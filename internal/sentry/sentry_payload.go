@@ -7,12 +7,9 @@ import (
 )
 
 type WebhookPayload struct {
-	Event  Event
-	Action EventAction `json:"action"`
-	Actor  struct {
-		Name string `json:"name"`
-		Type string `json:"type"`
-	} `json:"actor"`
+	Event        Event
+	Action       EventAction `json:"action"`
+	Actor        Actor       `json:"actor"`
 	Installation struct {
 		Uuid string `json:"uuid"`
 	} `json:"installation"`
@@ -22,8 +19,7 @@ type WebhookPayload struct {
 func (w *WebhookPayload) UnmarshalJSON(data []byte) error {
 	// Correctly set the Data interface type
 	switch w.Event {
-	case InstallationEvent:
-	case UninstallationEvent:
+	case InstallationEvent, UninstallationEvent:
 		w.Data = new(InstallationData)
 	case IssueAlertEvent:
 		w.Data = new(IssueAlertData)
@@ -41,6 +37,12 @@ func (w *WebhookPayload) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, (*tmp)(w))
 }
 
+type Actor struct {
+	Id   interface{} `json:"id"`
+	Name string      `json:"name"`
+	Type string      `json:"type"`
+}
+
 type InstallationData struct {
 	Installation struct {
 		Status       string `json:"status"`
@@ -101,7 +103,7 @@ type IssueAlertData struct {
 						} `json:"data"`
 						Errors          interface{} `json:"errors"`
 						Filename        string      `json:"filename"`
-						Function        interface{} `json:"function"`
+						Function        *string     `json:"function"`
 						ImageAddr       interface{} `json:"image_addr"`
 						InApp           bool        `json:"in_app"`
 						InstructionAddr interface{} `json:"instruction_addr"`
@@ -183,25 +185,25 @@ type MetricAlertData struct {
 	DescriptionTitle string `json:"description_title"`
 	MetricAlert      struct {
 		AlertRule struct {
-			Aggregate          string        `json:"aggregate"`
-			CreatedBy          interface{}   `json:"created_by"`
-			Dataset            string        `json:"dataset"`
-			DateCreated        time.Time     `json:"date_created"`
-			DateModified       time.Time     `json:"date_modified"`
-			Environment        interface{}   `json:"environment"`
-			Id                 string        `json:"id"`
-			IncludeAllProjects bool          `json:"include_all_projects"`
-			Name               string        `json:"name"`
-			OrganizationId     string        `json:"organization_id"`
-			Projects           []string      `json:"projects"`
-			Query              string        `json:"query"`
-			Resolution         int           `json:"resolution"`
-			ResolveThreshold   interface{}   `json:"resolve_threshold"`
-			Status             int           `json:"status"`
-			ThresholdPeriod    int           `json:"threshold_period"`
-			ThresholdType      int           `json:"threshold_type"`
-			TimeWindow         int           `json:"time_window"`
-			Triggers           []interface{} `json:"triggers"`
+			Aggregate          string               `json:"aggregate"`
+			CreatedBy          interface{}          `json:"created_by"`
+			Dataset            string               `json:"dataset"`
+			DateCreated        time.Time            `json:"date_created"`
+			DateModified       time.Time            `json:"date_modified"`
+			Environment        interface{}          `json:"environment"`
+			Id                 string               `json:"id"`
+			IncludeAllProjects bool                 `json:"include_all_projects"`
+			Name               string               `json:"name"`
+			OrganizationId     string               `json:"organization_id"`
+			Projects           []string             `json:"projects"`
+			Query              string               `json:"query"`
+			Resolution         int                  `json:"resolution"`
+			ResolveThreshold   *float64             `json:"resolve_threshold"`
+			Status             int                  `json:"status"`
+			ThresholdPeriod    int                  `json:"threshold_period"`
+			ThresholdType      int                  `json:"threshold_type"`
+			TimeWindow         int                  `json:"time_window"`
+			Triggers           []MetricAlertTrigger `json:"triggers"`
 		} `json:"alert_rule"`
 		DateClosed     interface{} `json:"date_closed"`
 		DateCreated    time.Time   `json:"date_created"`
@@ -219,10 +221,23 @@ type MetricAlertData struct {
 	WebUrl string `json:"web_url"`
 }
 
+const (
+	ThresholdTypeAbove = 0
+	ThresholdTypeBelow = 1
+)
+
+type MetricAlertTrigger struct {
+	Id               string   `json:"id"`
+	Label            string   `json:"label"`
+	AlertThreshold   float64  `json:"alert_threshold"`
+	ResolveThreshold *float64 `json:"resolve_threshold"`
+	ThresholdType    int      `json:"threshold_type"`
+}
+
 type IssueData struct {
 	Issue struct {
 		Annotations  []interface{} `json:"annotations"`
-		AssignedTo   interface{}   `json:"assignedTo"`
+		AssignedTo   *Assignee     `json:"assignedTo"`
 		Count        string        `json:"count"`
 		Culprit      string        `json:"culprit"`
 		FirstSeen    time.Time     `json:"firstSeen"`
@@ -248,18 +263,50 @@ type IssueData struct {
 			Platform string `json:"platform"`
 			Slug     string `json:"slug"`
 		} `json:"project"`
-		ShareId       interface{} `json:"shareId"`
-		ShortId       string      `json:"shortId"`
-		Status        string      `json:"status"`
-		StatusDetails struct {
-		} `json:"statusDetails"`
-		SubscriptionDetails interface{} `json:"subscriptionDetails"`
-		Title               string      `json:"title"`
-		Type                string      `json:"type"`
-		UserCount           int         `json:"userCount"`
+		ShareId             interface{}   `json:"shareId"`
+		ShortId             string        `json:"shortId"`
+		Status              string        `json:"status"`
+		Substatus           string        `json:"substatus"`
+		StatusDetails       StatusDetails `json:"statusDetails"`
+		SubscriptionDetails interface{}   `json:"subscriptionDetails"`
+		Title               string        `json:"title"`
+		Type                string        `json:"type"`
+		UserCount           int           `json:"userCount"`
+		WebUrl              string        `json:"web_url"`
+		ProjectUrl          string        `json:"project_url"`
 	} `json:"issue"`
 }
 
+const (
+	IssueStatusResolved   = "resolved"
+	IssueStatusUnresolved = "unresolved"
+	IssueStatusIgnored    = "ignored"
+
+	IssueSubstatusRegressed  = "regressed"
+	IssueSubstatusEscalating = "escalating"
+)
+
+type Assignee struct {
+	Type  string `json:"type"`
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// StatusDetails tells until when an issue is ignored or in what it was resolved.
+type StatusDetails struct {
+	IgnoreDuration        int         `json:"ignoreDuration"`
+	IgnoreUntil           *time.Time  `json:"ignoreUntil"`
+	IgnoreCount           int         `json:"ignoreCount"`
+	IgnoreWindow          int         `json:"ignoreWindow"`
+	IgnoreUserCount       int         `json:"ignoreUserCount"`
+	IgnoreUserWindow      int         `json:"ignoreUserWindow"`
+	IgnoreUntilEscalating bool        `json:"ignoreUntilEscalating"`
+	InNextRelease         bool        `json:"inNextRelease"`
+	InRelease             string      `json:"inRelease"`
+	InCommit              interface{} `json:"inCommit"`
+}
+
 type ErrorData struct {
 	Error struct {
 		Ref        int `json:"_ref"`
@@ -42,6 +42,8 @@ const (
 	IssueResolvedAction       EventAction = "resolved"
 	IssueAssignedAction       EventAction = "assigned"
 	IssueIgnoredAction        EventAction = "ignored"
+	IssueArchivedAction       EventAction = "archived"
+	IssueUnresolvedAction     EventAction = "unresolved"
 	ErrorCreatedAction        EventAction = "created"
 )
 
@@ -77,16 +79,27 @@ func WebhookResource(req *http.Request) string {
 }
 
 func ParseWebhook(resource string, payload []byte) (EventAction, interface{}, error) {
+	ctx, err := ParseWebhookPayload(resource, payload)
+	if err != nil {
+		return EventAction(""), nil, err
+	}
+
+	return ctx.Action, ctx.Data, nil
+}
+
+// ParseWebhookPayload is like ParseWebhook, but returns the whole payload
+// including the actor and installation.
+func ParseWebhookPayload(resource string, payload []byte) (*WebhookPayload, error) {
 	if len(resource) == 0 {
-		return EventAction(""), nil, ErrMissingResourceHeader
+		return nil, ErrMissingResourceHeader
 	}
 
 	ctx := new(WebhookPayload)
 	ctx.Event = Event(resource)
 
 	if err := json.Unmarshal(payload, &ctx); err != nil {
-		return EventAction(""), nil, err
+		return nil, err
 	}
 
-	return ctx.Action, ctx.Data, nil
+	return ctx, nil
 }
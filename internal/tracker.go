@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"github.com/rs/zerolog/log"
+	"go.etcd.io/bbolt"
+	"simplerick/internal/discord"
+	"sync"
+	"time"
+)
+
+const trackerEvictionInterval = time.Hour
+
+// Evictions collects stores that grow along with the tracked messages, so they
+// are evicted periodically together with the tracker.
+type Evictions struct {
+	mu       sync.Mutex
+	evictors []evictor
+}
+
+type evictor struct {
+	name  string
+	evict func() (int, error)
+}
+
+func ProvideEvictions() *Evictions {
+	return &Evictions{}
+}
+
+// Register adds the store to the periodic eviction. Evict returns how many of
+// the entries, named like the store, were removed.
+func (e *Evictions) Register(name string, evict func() (int, error)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.evictors = append(e.evictors, evictor{name, evict})
+}
+
+func (e *Evictions) run() {
+	e.mu.Lock()
+	evictors := append([]evictor(nil), e.evictors...)
+	e.mu.Unlock()
+
+	for _, ev := range evictors {
+		evicted, err := ev.evict()
+		if err != nil {
+			log.Error().Err(err).Msgf("[Main] Failed to evict %s", ev.name)
+			continue
+		}
+		if evicted > 0 {
+			log.Info().Msgf("[Main] Evicted %d %s", evicted, ev.name)
+		}
+	}
+}
+
+func ProvideTracker(config TrackerConfig, db *bbolt.DB, evictions *Evictions) (discord.Tracker, func(), error) {
+	var tracker discord.Tracker
+	if config.Store == "memory" {
+		tracker = discord.NewMemoryTracker(config.TTL, config.MaxEntries)
+	} else {
+		boltTracker, err := discord.NewBoltTracker(db, config.TTL, config.MaxEntries)
+		if err != nil {
+			return nil, nil, err
+		}
+		tracker = boltTracker
+	}
+
+	// Evict messages periodically, so the tracker does not grow forever
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(trackerEvictionInterval)
+		defer ticker.Stop()
+
+		for {
+			evict(tracker)
+			evictions.run()
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	cleanup := func() {
+		close(stop)
+	}
+
+	return tracker, cleanup, nil
+}
+
+func evict(tracker discord.Tracker) {
+	evicted, err := tracker.Evict()
+	if err != nil {
+		log.Error().Err(err).Msg("[Discord] Failed to evict tracked messages")
+		return
+	}
+	if evicted == 0 {
+		return
+	}
+
+	stats, err := tracker.Stats()
+	if err != nil {
+		log.Error().Err(err).Msg("[Discord] Failed to read tracker stats")
+		return
+	}
+	log.Info().
+		Int("entries", stats.Entries).
+		Uint64("expired_evictions", stats.ExpiredEvictions).
+		Uint64("capacity_evictions", stats.CapacityEvictions).
+		Msgf("[Discord] Evicted %d tracked messages", evicted)
+}
@@ -1,9 +1,71 @@
 package utils
 
+import (
+	"strings"
+	"unicode"
+)
+
+const ellipsis = "..."
+
+// Ellipsis truncates the text to at most length characters, ending it with an
+// ellipsis when it had to be cut.
 func Ellipsis(text string, length int) string {
-	textLen := len(text)
-	if textLen <= length {
+	if length <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	if len(runes) <= length {
 		return text
 	}
-	return text[:textLen-1-3] + "..."
+
+	if length <= len(ellipsis) {
+		return string(runes[:length])
+	}
+
+	return string(runes[:length-len(ellipsis)]) + ellipsis
+}
+
+// EllipsisWords truncates the text to at most length characters like Ellipsis,
+// but cuts at the last word boundary so no word is split in half. Falls back to
+// cutting mid-word when the text contains no boundary to cut at.
+func EllipsisWords(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	if length <= len(ellipsis) {
+		return Ellipsis(text, length)
+	}
+
+	cut := length - len(ellipsis)
+	for i := cut; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return strings.TrimRightFunc(string(runes[:i]), unicode.IsSpace) + ellipsis
+		}
+	}
+
+	return Ellipsis(text, length)
+}
+
+// EllipsisLines keeps at most the given amount of lines of the text, ending it
+// with an ellipsis when lines had to be dropped.
+func EllipsisLines(text string, lines int) string {
+	if lines <= 0 {
+		return ""
+	}
+
+	split := strings.SplitN(text, "\n", lines+1)
+	if len(split) <= lines {
+		return text
+	}
+
+	// Only blank lines were dropped, e.g. a trailing newline
+	kept := strings.Join(split[:lines], "\n")
+	if len(strings.TrimSpace(split[lines])) == 0 {
+		return kept
+	}
+
+	return kept + ellipsis
 }
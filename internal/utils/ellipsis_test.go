@@ -0,0 +1,86 @@
+package utils
+
+import "testing"
+
+func TestEllipsis(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		length int
+		want   string
+	}{
+		{"fits", "hello", 5, "hello"},
+		{"shorter", "hello", 10, "hello"},
+		{"cut", "hello world", 8, "hello..."},
+		{"zero length", "hello", 0, ""},
+		{"negative length", "hello", -1, ""},
+		{"no room for ellipsis", "hello", 3, "hel"},
+		{"room for one rune", "hello", 4, "h..."},
+		{"empty", "", 3, ""},
+		{"runes fit", "héllo", 5, "héllo"},
+		{"cut at rune boundary", "日本語のテキスト", 6, "日本語..."},
+		{"cut multi byte without ellipsis", "日本語のテキスト", 2, "日本"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Ellipsis(tt.text, tt.length); got != tt.want {
+				t.Errorf("Ellipsis(%q, %d) = %q, want %q", tt.text, tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEllipsisWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		length int
+		want   string
+	}{
+		{"fits", "hello world", 11, "hello world"},
+		{"cut at word boundary", "hello world again", 14, "hello world..."},
+		{"cut before split word", "hello world", 10, "hello..."},
+		{"boundary right at cut", "hello world", 8, "hello..."},
+		{"trims repeated spaces", "hello   world again", 12, "hello..."},
+		{"falls back mid word", "helloworld", 8, "hello..."},
+		{"leading space only", " helloworld", 8, " hell..."},
+		{"no room for ellipsis", "hello world", 3, "hel"},
+		{"zero length", "hello world", 0, ""},
+		{"runes", "日本 語のテキスト", 7, "日本..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EllipsisWords(tt.text, tt.length); got != tt.want {
+				t.Errorf("EllipsisWords(%q, %d) = %q, want %q", tt.text, tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEllipsisLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		lines int
+		want  string
+	}{
+		{"fits", "a\nb", 2, "a\nb"},
+		{"single line", "a", 1, "a"},
+		{"cut", "a\nb\nc", 2, "a\nb..."},
+		{"cut to one", "a\nb", 1, "a..."},
+		{"zero lines", "a\nb", 0, ""},
+		{"trailing newline", "a\nb\n", 2, "a\nb"},
+		{"trailing blank lines", "a\nb\n\n \n", 2, "a\nb"},
+		{"empty", "", 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EllipsisLines(tt.text, tt.lines); got != tt.want {
+				t.Errorf("EllipsisLines(%q, %d) = %q, want %q", tt.text, tt.lines, got, tt.want)
+			}
+		})
+	}
+}
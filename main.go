@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/wire"
 	"github.com/gorilla/mux"
@@ -11,11 +14,17 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 	"io"
 	"net/http"
+	"os"
+	"os/signal"
 	"path"
+	"simplerick/admin"
+	"simplerick/internal"
+	"simplerick/internal/discord"
 	"simplerick/internal/env"
 	"simplerick/internal/logging"
 	github_webhook "simplerick/webhooks/github"
 	sentry_webhook "simplerick/webhooks/sentry"
+	"syscall"
 	"time"
 )
 
@@ -41,20 +50,63 @@ func setupSentry() bool {
 		return false
 	}
 
-	err := sentry.Init(sentry.ClientOptions{
-		Dsn:         sentryDsn,
-		Release:     "simplerick@0.1.0",
-		Environment: "development",
-	})
-
+	options, err := sentryOptions(sentryDsn)
 	if err != nil {
+		log.Fatal().Err(err).Msg("[Main] Invalid Sentry configuration")
+	}
+
+	if err = sentry.Init(options); err != nil {
 		log.Fatal().Err(err).Msg("Sentry init failed")
 	}
 
+	log.Debug().Msgf("[Main] Sentry enabled for release %s in %s", options.Release, options.Environment)
 	return true
 }
 
+func sentryOptions(dsn string) (sentry.ClientOptions, error) {
+	sampleRate, err := env.GetFloat("SENTRY_SAMPLE_RATE", 1)
+	if err != nil {
+		return sentry.ClientOptions{}, fmt.Errorf("environment variable SENTRY_SAMPLE_RATE is invalid: %w", err)
+	}
+	tracesSampleRate, err := env.GetFloat("SENTRY_TRACES_SAMPLE_RATE", 0)
+	if err != nil {
+		return sentry.ClientOptions{}, fmt.Errorf("environment variable SENTRY_TRACES_SAMPLE_RATE is invalid: %w", err)
+	}
+	debug, err := env.GetBool("SENTRY_DEBUG", false)
+	if err != nil {
+		return sentry.ClientOptions{}, fmt.Errorf("environment variable SENTRY_DEBUG is invalid: %w", err)
+	}
+
+	if sampleRate <= 0 || sampleRate > 1 {
+		return sentry.ClientOptions{}, errors.New("environment variable SENTRY_SAMPLE_RATE must be above 0 and at most 1")
+	}
+
+	if tracesSampleRate < 0 || tracesSampleRate > 1 {
+		return sentry.ClientOptions{}, errors.New("environment variable SENTRY_TRACES_SAMPLE_RATE must be between 0 and 1")
+	}
+
+	return sentry.ClientOptions{
+		Dsn:              dsn,
+		Release:          env.GetString("SENTRY_RELEASE", release()),
+		Environment:      env.GetString("SENTRY_ENVIRONMENT", "production"),
+		SampleRate:       sampleRate,
+		TracesSampleRate: tracesSampleRate,
+		ServerName:       env.GetString("SENTRY_SERVER_NAME", ""),
+		Debug:            debug,
+	}, nil
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "dead-letters" {
+		os.Exit(runDeadLettersCommand(os.Args[2:]))
+	}
+
+	os.Exit(run())
+}
+
+// run starts the application and blocks until it stopped, returning the exit
+// code. Kept apart from main, so the deferred cleanup runs before exiting.
+func run() int {
 	setupLogger()
 
 	if setupSentry() {
@@ -62,38 +114,107 @@ func main() {
 	}
 
 	ctx := context.Background()
-	app, err := setupApplication(ctx)
+	app, cleanup, err := setupApplication(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("[Main] Failed to set up application")
+		log.Error().Err(err).Msg("[Main] Failed to set up application")
+		return 1
 	}
+	defer cleanup()
 
-	if err = app.Start(); err != nil {
-		log.Fatal().Err(err).Msg("[Main] Failed to start application")
+	if err = app.Run(); err != nil {
+		log.Error().Err(err).Msg("[Main] Failed to run application")
+		return 1
 	}
+
+	return 0
 }
 
 var applicationSet = wire.NewSet(
 	newApplication,
 	newRouter,
+	admin.ProvideHandler,
 	wire.Bind(new(http.Handler), new(*mux.Router)),
 )
 
-func newRouter(githubWebhook github_webhook.WebhookHandler, sentryWebhook sentry_webhook.WebhookHandler) *mux.Router {
+func newRouter(githubWebhook github_webhook.WebhookHandler, sentryWebhook sentry_webhook.WebhookHandler, adminHandler admin.Handler) *mux.Router {
 	r := mux.NewRouter()
 	r.HandleFunc("/api/v1/webhooks/github", githubWebhook.Handler)
 	r.HandleFunc("/api/v1/webhooks/sentry", sentryWebhook.Handler)
+	adminHandler.Register(r)
 	return r
 }
 
-func newApplication(handler http.Handler) application {
-	return application{handler}
+func newApplication(handler http.Handler, executor *discord.Executor, config internal.ServerConfig) (application, error) {
+	server := &http.Server{
+		Addr:              config.Addr,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+
+	if config.TLSEnabled() {
+		reloader, err := internal.NewCertificateReloader(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return application{}, err
+		}
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+	}
+
+	return application{
+		server:   server,
+		executor: executor,
+		config:   config,
+	}, nil
 }
 
 type application struct {
-	handler http.Handler
+	server   *http.Server
+	executor *discord.Executor
+	config   internal.ServerConfig
 }
 
-func (app application) Start() error {
-	log.Info().Msg("[Main] Listening to port 3000")
-	return http.ListenAndServe(":3000", app.handler)
+// Run serves until the process is asked to stop, then shuts down gracefully:
+// the server stops accepting calls and waits for the running ones, after which
+// the Discord queues get to drain within what is left of the timeout.
+func (app application) Run() error {
+	errs := make(chan error, 1)
+	go func() {
+		if app.config.TLSEnabled() {
+			log.Info().Msgf("[Main] Listening on %s with TLS", app.server.Addr)
+			errs <- app.server.ListenAndServeTLS("", "")
+		} else {
+			log.Info().Msgf("[Main] Listening on %s", app.server.Addr)
+			errs <- app.server.ListenAndServe()
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		log.Info().Msgf("[Main] Received %s, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.ShutdownTimeout)
+	defer cancel()
+
+	if err := app.server.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("[Main] Failed to shut down server gracefully")
+	}
+	if err := app.executor.Shutdown(ctx); err != nil {
+		log.Warn().Err(err).Msg("[Main] Failed to drain Discord queues in time")
+	}
+
+	log.Info().Msg("[Main] Shut down")
+	return nil
 }
@@ -0,0 +1,21 @@
+package main
+
+import "runtime/debug"
+
+// version is set at build time, e.g. with -ldflags "-X main.version=1.2.0".
+var version string
+
+// release returns the release of the build, which is reported to Sentry. Falls
+// back to the module version when built with go install, as debug builds do
+// not have a version.
+func release() string {
+	if len(version) > 0 {
+		return "simplerick@" + version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return "simplerick@" + info.Main.Version
+	}
+
+	return "simplerick@dev"
+}
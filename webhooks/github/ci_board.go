@@ -0,0 +1,94 @@
+package github
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ciBoardRetention is how long the state of a commit is kept after its last
+// update, a late event after that simply starts a new board.
+const ciBoardRetention = 24 * time.Hour
+
+type ciRun struct {
+	name        string
+	workflow    bool
+	status      string
+	conclusion  string
+	url         string
+	startedAt   time.Time
+	completedAt time.Time
+}
+
+func (r ciRun) duration() time.Duration {
+	if r.startedAt.IsZero() || r.completedAt.IsZero() {
+		return 0
+	}
+	return r.completedAt.Sub(r.startedAt).Round(time.Second)
+}
+
+type ciCommit struct {
+	repo          string
+	branch        string
+	sha           string
+	defaultBranch bool
+	runs          map[string]ciRun
+	updatedAt     time.Time
+}
+
+// ciBoard keeps the state of every check and workflow run per commit, so the
+// tracked Discord message can be re-rendered with all of them.
+type ciBoard struct {
+	mu      sync.Mutex
+	commits map[string]*ciCommit
+}
+
+func newCIBoard() *ciBoard {
+	return &ciBoard{
+		commits: make(map[string]*ciCommit),
+	}
+}
+
+// update stores the run for the commit and passes a snapshot of the commit with
+// its runs sorted, workflows first, to publish. Publish is called before the
+// board is unlocked, so snapshots of concurrent updates are published in the
+// order they were taken.
+func (b *ciBoard) update(key string, commit ciCommit, runKey string, run ciRun, publish func(ciCommit, []ciRun) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for k, c := range b.commits {
+		if now.Sub(c.updatedAt) > ciBoardRetention {
+			delete(b.commits, k)
+		}
+	}
+
+	c, ok := b.commits[key]
+	if !ok {
+		c = &commit
+		c.runs = make(map[string]ciRun)
+		b.commits[key] = c
+	}
+	if len(commit.branch) > 0 {
+		c.branch = commit.branch
+		c.defaultBranch = commit.defaultBranch
+	}
+	c.runs[runKey] = run
+	c.updatedAt = now
+
+	runs := make([]ciRun, 0, len(c.runs))
+	for _, r := range c.runs {
+		runs = append(runs, r)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].workflow != runs[j].workflow {
+			return runs[i].workflow
+		}
+		return runs[i].name < runs[j].name
+	})
+
+	snapshot := *c
+	snapshot.runs = nil
+	return publish(snapshot, runs)
+}
@@ -0,0 +1,27 @@
+package github
+
+import (
+	"github.com/google/go-github/github"
+	"net/http"
+	"time"
+)
+
+type tokenTransport struct {
+	token string
+}
+
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newClient creates a GitHub API client, authenticated when a token is given.
+// Without a token only public repositories can be queried.
+func newClient(token string) *github.Client {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	if len(token) > 0 {
+		httpClient.Transport = tokenTransport{token}
+	}
+	return github.NewClient(httpClient)
+}
@@ -0,0 +1,50 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// joinFieldLines joins the lines while keeping them within the value limit of
+// an embed field.
+func joinFieldLines(lines []string) string {
+	const maxLength = 1024
+
+	var sb strings.Builder
+	for i, line := range lines {
+		more := fmt.Sprintf("and %d more", len(lines)-i)
+		reserved := len(line) + 1
+		if i < len(lines)-1 {
+			reserved += len(more)
+		}
+		if sb.Len()+reserved > maxLength {
+			sb.WriteString(more)
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"errors"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/go-github/github"
 	"github.com/rs/zerolog/log"
@@ -12,12 +13,16 @@ import (
 type WebhookHandler struct {
 	executor *discord.Executor
 	config   internal.GithubWebhookConfig
+	client   *github.Client
+	ci       *ciBoard
 }
 
 func ProvideWebhookHandler(executor *discord.Executor, config internal.GithubWebhookConfig) WebhookHandler {
 	return WebhookHandler{
 		executor: executor,
 		config:   config,
+		client:   newClient(config.ApiToken),
+		ci:       newCIBoard(),
 	}
 }
 
@@ -42,7 +47,7 @@ func (h WebhookHandler) Handler(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 
-	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	event, err := parseWebHook(github.WebHookType(r), payload)
 	if err != nil {
 		log.Error().Err(err).Msg("[GitHub] Failed to parse payload")
 		w.WriteHeader(http.StatusBadRequest)
@@ -56,8 +61,30 @@ func (h WebhookHandler) Handler(w http.ResponseWriter, r *http.Request) {
 		err = h.handleCreateEvent(e)
 	case *github.DeleteEvent:
 		err = h.handleDeleteEvent(e)
+	case *github.ReleaseEvent:
+		err = h.handleReleaseEvent(e)
+	case *PullRequestEvent:
+		err = h.handlePullRequestEvent(e)
+	case *github.PullRequestReviewEvent:
+		err = h.handlePullRequestReviewEvent(e)
+	case *github.PullRequestReviewCommentEvent:
+		err = h.handlePullRequestReviewCommentEvent(e)
+	case *github.IssuesEvent:
+		err = h.handleIssuesEvent(e)
+	case *github.IssueCommentEvent:
+		err = h.handleIssueCommentEvent(e)
+	case *WorkflowRunEvent:
+		err = h.handleWorkflowRunEvent(e)
+	case *github.CheckSuiteEvent:
+		err = h.handleCheckSuiteEvent(e)
+	case *github.CheckRunEvent:
+		err = h.handleCheckRunEvent(e)
 	}
 
+	if errors.Is(err, discord.ErrQueueFull) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("[GitHub] Failed to process payload")
 		w.WriteHeader(http.StatusBadRequest)
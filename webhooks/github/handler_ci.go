@@ -0,0 +1,229 @@
+package github
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-github/github"
+	"simplerick/internal/discord"
+)
+
+const (
+	ciPendingColor              = 0xF1C40F
+	ciSuccessColor              = 0x2ECC71
+	ciFailureColor              = 0xE74C3C
+	ciDefaultBranchFailureColor = 0x992D22
+)
+
+func (h WebhookHandler) handleWorkflowRunEvent(event *WorkflowRunEvent) error {
+	run := event.WorkflowRun
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling workflow run event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"action": event.GetAction(),
+			"name":   run.GetName(),
+			"sha":    run.GetHeadSHA(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	ciRun := ciRun{
+		name:       run.GetName(),
+		workflow:   true,
+		status:     run.GetStatus(),
+		conclusion: run.GetConclusion(),
+		url:        run.GetHTMLURL(),
+		startedAt:  run.startedAt().Time,
+	}
+	if run.GetStatus() == "completed" && run.UpdatedAt != nil {
+		ciRun.completedAt = run.UpdatedAt.Time
+	}
+
+	return h.updateCIBoard(event.Repo, run.GetHeadBranch(), run.GetHeadSHA(), fmt.Sprintf("workflow/%d", run.GetID()), ciRun)
+}
+
+func (h WebhookHandler) handleCheckSuiteEvent(event *github.CheckSuiteEvent) error {
+	suite := event.CheckSuite
+
+	// Suites of GitHub Actions are already reported through their workflow runs
+	if suite.GetApp().GetName() == "GitHub Actions" {
+		return nil
+	}
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling check suite event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"action": event.GetAction(),
+			"app":    suite.GetApp().GetName(),
+			"sha":    suite.GetHeadSHA(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	ciRun := ciRun{
+		name:       suite.GetApp().GetName(),
+		workflow:   true,
+		status:     suite.GetStatus(),
+		conclusion: suite.GetConclusion(),
+	}
+
+	return h.updateCIBoard(event.Repo, suite.GetHeadBranch(), suite.GetHeadSHA(), fmt.Sprintf("suite/%d", suite.GetID()), ciRun)
+}
+
+func (h WebhookHandler) handleCheckRunEvent(event *github.CheckRunEvent) error {
+	check := event.CheckRun
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling check run event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"action": event.GetAction(),
+			"name":   check.GetName(),
+			"sha":    check.GetHeadSHA(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	ciRun := ciRun{
+		name:       check.GetName(),
+		status:     check.GetStatus(),
+		conclusion: check.GetConclusion(),
+		url:        check.GetHTMLURL(),
+	}
+	if check.StartedAt != nil {
+		ciRun.startedAt = check.StartedAt.Time
+	}
+	if check.CompletedAt != nil {
+		ciRun.completedAt = check.CompletedAt.Time
+	}
+
+	return h.updateCIBoard(event.Repo, check.GetCheckSuite().GetHeadBranch(), check.GetHeadSHA(), fmt.Sprintf("check/%d", check.GetID()), ciRun)
+}
+
+func (h WebhookHandler) updateCIBoard(repo *github.Repository, branch, sha, runKey string, run ciRun) error {
+	key := fmt.Sprintf("github/%s/ci/%s", repo.GetFullName(), sha)
+	commit := ciCommit{
+		repo:          repo.GetFullName(),
+		branch:        branch,
+		sha:           sha,
+		defaultBranch: len(branch) > 0 && branch == repo.GetDefaultBranch(),
+	}
+
+	return h.ci.update(key, commit, runKey, run, func(commit ciCommit, runs []ciRun) error {
+		embed, err := buildCIBoardEmbed(repo, commit, runs)
+		if err != nil {
+			return err
+		}
+
+		return h.executor.EnqueueEmbed(h.config.CIWebhookUrl, embed, discord.WithTrackingKey(key))
+	})
+}
+
+func buildCIBoardEmbed(repo *github.Repository, commit ciCommit, runs []ciRun) (discord.Embed, error) {
+	description := fmt.Sprintf("`%s` on **%s**", shortSha(commit.sha), commit.repo)
+	if len(commit.branch) > 0 {
+		description = fmt.Sprintf("`%s` on branch **%s** of **%s**", shortSha(commit.sha), commit.branch, commit.repo)
+	}
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetURL(fmt.Sprintf("%s/commit/%s", repo.GetHTMLURL(), commit.sha)).
+		SetDescription(description).
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	pending, failed := false, false
+	var failedRun *ciRun
+	var workflows, jobs []string
+	for i, run := range runs {
+		line := fmt.Sprintf("%s %s", ciStatusEmoji(run), run.name)
+		if len(run.url) > 0 {
+			line = fmt.Sprintf("%s [%s](%s)", ciStatusEmoji(run), run.name, run.url)
+		}
+		if d := run.duration(); d > 0 {
+			line += fmt.Sprintf(" (%s)", d)
+		}
+
+		if run.workflow {
+			workflows = append(workflows, line)
+		} else {
+			jobs = append(jobs, line)
+		}
+
+		switch {
+		case run.status != "completed":
+			pending = true
+		case isCIFailure(run.conclusion):
+			failed = true
+			if failedRun == nil {
+				failedRun = &runs[i]
+			}
+		}
+	}
+
+	if len(workflows) > 0 {
+		builder.AddField("Workflows", joinFieldLines(workflows))
+	}
+	if len(jobs) > 0 {
+		builder.AddField("Jobs", joinFieldLines(jobs))
+	}
+
+	switch {
+	case failed && commit.defaultBranch:
+		builder.
+			SetTitle(fmt.Sprintf("CI failing on default branch %s", commit.branch)).
+			SetColor(ciDefaultBranchFailureColor)
+	case failed:
+		builder.
+			SetTitle("CI failed").
+			SetColor(ciFailureColor)
+	case pending:
+		builder.
+			SetTitle("CI running").
+			SetColor(ciPendingColor)
+	default:
+		builder.
+			SetTitle("CI passed").
+			SetColor(ciSuccessColor)
+	}
+
+	if failedRun != nil && len(failedRun.url) > 0 {
+		builder.AddField("Failing run", fmt.Sprintf("[%s](%s)", failedRun.name, failedRun.url))
+	}
+
+	return builder.Build()
+}
+
+func isCIFailure(conclusion string) bool {
+	switch conclusion {
+	case "failure", "timed_out", "action_required", "startup_failure":
+		return true
+	}
+	return false
+}
+
+func ciStatusEmoji(run ciRun) string {
+	switch run.status {
+	case "queued", "requested", "waiting", "pending":
+		return "⏳"
+	case "in_progress":
+		return "🔄"
+	}
+
+	switch run.conclusion {
+	case "success":
+		return "✅"
+	case "skipped", "neutral":
+		return "⏭️"
+	case "cancelled", "stale":
+		return "⚪"
+	}
+	if isCIFailure(run.conclusion) {
+		return "❌"
+	}
+	return "❔"
+}
@@ -1,11 +1,18 @@
 package github
 
 import (
+	"context"
 	"fmt"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/go-github/github"
 	"github.com/rs/zerolog/log"
 	"simplerick/internal/discord"
+	"time"
+)
+
+const (
+	branchColor = 0x00BCD4
+	tagColor    = 0x9B59B6
 )
 
 func (h WebhookHandler) handleCreateEvent(event *github.CreateEvent) error {
@@ -14,7 +21,7 @@ func (h WebhookHandler) handleCreateEvent(event *github.CreateEvent) error {
 		return nil
 	}
 
-	if *event.RefType != "branch" {
+	if *event.RefType != "branch" && *event.RefType != "tag" {
 		return nil
 	}
 
@@ -25,12 +32,17 @@ func (h WebhookHandler) handleCreateEvent(event *github.CreateEvent) error {
 			"repo":   *event.Repo.Name,
 			"sender": *event.Sender.Login,
 			"ref":    *event.Ref,
+			"type":   *event.RefType,
 		},
 		Level: sentry.LevelInfo,
 	})
 
+	if *event.RefType == "tag" {
+		return h.handleCreateTag(event)
+	}
+
 	builder := discord.NewEmbedBuilder().
-		SetColor(0x00BCD4).
+		SetColor(branchColor).
 		SetAuthor(*event.Sender.Login,
 			discord.WithAuthorUrl(*event.Sender.HTMLURL),
 			discord.WithAuthorIcon(*event.Sender.AvatarURL)).
@@ -39,9 +51,12 @@ func (h WebhookHandler) handleCreateEvent(event *github.CreateEvent) error {
 		SetFooter("Simple Rick - GitHub").
 		AddTimestamp()
 
-	h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, builder.Build())
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, embed)
 }
 
 func (h WebhookHandler) handleDeleteEvent(event *github.DeleteEvent) error {
@@ -50,7 +65,7 @@ func (h WebhookHandler) handleDeleteEvent(event *github.DeleteEvent) error {
 		return nil
 	}
 
-	if *event.RefType != "branch" {
+	if *event.RefType != "branch" && *event.RefType != "tag" {
 		return nil
 	}
 
@@ -61,12 +76,31 @@ func (h WebhookHandler) handleDeleteEvent(event *github.DeleteEvent) error {
 			"repo":   *event.Repo.Name,
 			"sender": *event.Sender.Login,
 			"ref":    *event.Ref,
+			"type":   *event.RefType,
 		},
 		Level: sentry.LevelInfo,
 	})
 
+	if *event.RefType == "tag" {
+		builder := discord.NewEmbedBuilder().
+			SetColor(tagColor).
+			SetAuthor(*event.Sender.Login,
+				discord.WithAuthorUrl(*event.Sender.HTMLURL),
+				discord.WithAuthorIcon(*event.Sender.AvatarURL)).
+			SetDescription(fmt.Sprintf("Deleted tag **%s** of **%s**", *event.Ref, *event.Repo.Name)).
+			SetFooter("Simple Rick - GitHub").
+			AddTimestamp()
+
+		embed, err := builder.Build()
+		if err != nil {
+			return err
+		}
+
+		return h.executor.EnqueueEmbed(h.config.TagsWebhookUrl, embed)
+	}
+
 	builder := discord.NewEmbedBuilder().
-		SetColor(0x00BCD4).
+		SetColor(branchColor).
 		SetAuthor(*event.Sender.Login,
 			discord.WithAuthorUrl(*event.Sender.HTMLURL),
 			discord.WithAuthorIcon(*event.Sender.AvatarURL)).
@@ -74,7 +108,52 @@ func (h WebhookHandler) handleDeleteEvent(event *github.DeleteEvent) error {
 		SetFooter("Simple Rick - GitHub").
 		AddTimestamp()
 
-	h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, builder.Build())
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, embed)
+}
+
+func (h WebhookHandler) handleCreateTag(event *github.CreateEvent) error {
+	builder := discord.NewEmbedBuilder().
+		SetColor(tagColor).
+		SetAuthor(*event.Sender.Login,
+			discord.WithAuthorUrl(*event.Sender.HTMLURL),
+			discord.WithAuthorIcon(*event.Sender.AvatarURL)).
+		SetURL(fmt.Sprintf("%s/tree/%s", *event.Repo.HTMLURL, *event.Ref)).
+		SetDescription(fmt.Sprintf("Created tag **%s** on **%s**", *event.Ref, *event.Repo.Name)).
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	// Stay well within the 10s GitHub waits for the delivery to be answered
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The event does not contain the tagged commit, it is looked up instead but
+	// the tag is announced regardless.
+	info, err := h.lookupTag(ctx, event.Repo, *event.Ref)
+	if err != nil {
+		log.Warn().Err(err).Msgf("[GitHub] Failed to look up tag %s", *event.Ref)
+	}
+
+	if len(info.sha) > 0 {
+		builder.AddField("Commit",
+			fmt.Sprintf("[`%s`](%s/commit/%s)", shortSha(info.sha), *event.Repo.HTMLURL, info.sha),
+			discord.WithFieldInline())
+	}
+
+	if len(info.previousTag) > 0 {
+		builder.AddField("Changes",
+			fmt.Sprintf("[%s...%s](%s/compare/%s...%s)", info.previousTag, *event.Ref, *event.Repo.HTMLURL, info.previousTag, *event.Ref),
+			discord.WithFieldInline())
+	}
+
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return h.executor.EnqueueEmbed(h.config.TagsWebhookUrl, embed)
 }
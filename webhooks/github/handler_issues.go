@@ -0,0 +1,142 @@
+package github
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-github/github"
+	"github.com/rs/zerolog/log"
+	"simplerick/internal/discord"
+	"simplerick/internal/utils"
+	"strings"
+)
+
+const (
+	issueOpenColor    = 0x2ECC71
+	issueClosedColor  = 0x6F42C1
+	issueCommentColor = 0x95A5A6
+)
+
+func (h WebhookHandler) handleIssuesEvent(event *github.IssuesEvent) error {
+	if *event.Sender.Type == "Bot" {
+		log.Debug().Msg("[GitHub] Ignored issues event from bot")
+		return nil
+	}
+
+	action := event.GetAction()
+	switch action {
+	case "opened", "closed", "reopened", "labeled", "unlabeled", "assigned", "unassigned":
+	default:
+		return nil
+	}
+
+	issue := event.Issue
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling issues event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"sender": *event.Sender.Login,
+			"action": action,
+			"number": issue.GetNumber(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(fmt.Sprintf("#%d %s", issue.GetNumber(), issue.GetTitle())).
+		SetURL(issue.GetHTMLURL()).
+		SetAuthor(issue.GetUser().GetLogin(),
+			discord.WithAuthorUrl(issue.GetUser().GetHTMLURL()),
+			discord.WithAuthorIcon(issue.GetUser().GetAvatarURL())).
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	if body := strings.TrimSpace(issue.GetBody()); len(body) > 0 {
+		builder.SetDescription(utils.EllipsisWords(body, 1024))
+	} else {
+		builder.SetDescription(fmt.Sprintf("on **%s**", *event.Repo.Name))
+	}
+
+	if issue.GetState() == "closed" {
+		builder.SetColor(issueClosedColor).AddField("Status", "Closed", discord.WithFieldInline())
+	} else {
+		builder.SetColor(issueOpenColor).AddField("Status", "Open", discord.WithFieldInline())
+	}
+
+	if len(issue.Assignees) > 0 {
+		assignees := make([]string, 0, len(issue.Assignees))
+		for _, assignee := range issue.Assignees {
+			assignees = append(assignees, assignee.GetLogin())
+		}
+		builder.AddField("Assignees", strings.Join(assignees, ", "), discord.WithFieldInline())
+	}
+
+	if len(issue.Labels) > 0 {
+		labels := make([]string, 0, len(issue.Labels))
+		for _, label := range issue.Labels {
+			labels = append(labels, fmt.Sprintf("`%s`", label.GetName()))
+		}
+		builder.AddField("Labels", strings.Join(labels, " "), discord.WithFieldInline())
+	}
+
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.IssuesWebhookUrl, embed, discord.WithTrackingKey(issueTrackingKey(event.Repo, issue.GetNumber())))
+}
+
+func (h WebhookHandler) handleIssueCommentEvent(event *github.IssueCommentEvent) error {
+	if *event.Sender.Type == "Bot" {
+		log.Debug().Msg("[GitHub] Ignored issue comment event from bot")
+		return nil
+	}
+
+	if event.GetAction() != "created" {
+		return nil
+	}
+
+	// Comments on pull requests are delivered as issue comments as well, those
+	// do not belong in the issues channel.
+	if event.Issue.PullRequestLinks != nil {
+		return nil
+	}
+
+	issue := event.Issue
+	comment := event.Comment
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling issue comment event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"sender": *event.Sender.Login,
+			"number": issue.GetNumber(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(fmt.Sprintf("Commented on #%d %s", issue.GetNumber(), issue.GetTitle())).
+		SetURL(comment.GetHTMLURL()).
+		SetColor(issueCommentColor).
+		SetAuthor(comment.GetUser().GetLogin(),
+			discord.WithAuthorUrl(comment.GetUser().GetHTMLURL()),
+			discord.WithAuthorIcon(comment.GetUser().GetAvatarURL())).
+		SetDescription(utils.EllipsisWords(comment.GetBody(), 1024)).
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.IssuesWebhookUrl, embed)
+}
+
+func issueTrackingKey(repo *github.Repository, number int) string {
+	return fmt.Sprintf("github/%s/issue/%d", repo.GetFullName(), number)
+}
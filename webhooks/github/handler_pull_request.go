@@ -0,0 +1,95 @@
+package github
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-github/github"
+	"github.com/rs/zerolog/log"
+	"simplerick/internal/discord"
+	"strings"
+)
+
+const (
+	pullRequestOpenColor   = 0x2ECC71
+	pullRequestMergedColor = 0x6F42C1
+	pullRequestClosedColor = 0xE74C3C
+	pullRequestDraftColor  = 0x95A5A6
+)
+
+func (h WebhookHandler) handlePullRequestEvent(event *PullRequestEvent) error {
+	if *event.Sender.Type == "Bot" {
+		log.Debug().Msg("[GitHub] Ignored pull request event from bot")
+		return nil
+	}
+
+	action := event.GetAction()
+	switch action {
+	case "opened", "ready_for_review", "synchronize", "closed", "reopened":
+	default:
+		return nil
+	}
+
+	pr := event.PullRequest
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling pull request event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"sender": *event.Sender.Login,
+			"action": action,
+			"number": pr.GetNumber(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(fmt.Sprintf("#%d %s", pr.GetNumber(), pr.GetTitle())).
+		SetURL(pr.GetHTMLURL()).
+		SetAuthor(pr.GetUser().GetLogin(),
+			discord.WithAuthorUrl(pr.GetUser().GetHTMLURL()),
+			discord.WithAuthorIcon(pr.GetUser().GetAvatarURL())).
+		SetDescription(fmt.Sprintf("`%s` → `%s` on **%s**", pr.GetHead().GetRef(), pr.GetBase().GetRef(), *event.Repo.Name)).
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	switch {
+	case pr.GetMerged():
+		builder.SetColor(pullRequestMergedColor).AddField("Status", "Merged", discord.WithFieldInline())
+	case pr.GetState() == "closed":
+		builder.SetColor(pullRequestClosedColor).AddField("Status", "Closed", discord.WithFieldInline())
+	case event.Draft:
+		builder.SetColor(pullRequestDraftColor).AddField("Status", "Draft", discord.WithFieldInline())
+	case action == "ready_for_review":
+		builder.SetColor(pullRequestOpenColor).AddField("Status", "Ready for review", discord.WithFieldInline())
+	default:
+		builder.SetColor(pullRequestOpenColor).AddField("Status", "Open", discord.WithFieldInline())
+	}
+
+	builder.
+		AddField("Commits", fmt.Sprintf("%d", pr.GetCommits()), discord.WithFieldInline()).
+		AddField("Changes", fmt.Sprintf("+%d / -%d", pr.GetAdditions(), pr.GetDeletions()), discord.WithFieldInline())
+
+	if len(pr.RequestedReviewers) > 0 {
+		reviewers := make([]string, 0, len(pr.RequestedReviewers))
+		for _, reviewer := range pr.RequestedReviewers {
+			reviewers = append(reviewers, reviewer.GetLogin())
+		}
+		builder.AddField("Reviewers", strings.Join(reviewers, ", "))
+	}
+
+	if pr.GetMerged() && pr.MergedBy != nil {
+		builder.AddField("Merged by", pr.MergedBy.GetLogin(), discord.WithFieldInline())
+	}
+
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, embed, discord.WithTrackingKey(pullRequestTrackingKey(event.Repo, pr.GetNumber())))
+}
+
+func pullRequestTrackingKey(repo *github.Repository, number int) string {
+	return fmt.Sprintf("github/%s/pull/%d", repo.GetFullName(), number)
+}
@@ -0,0 +1,185 @@
+package github
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-github/github"
+	"github.com/rs/zerolog/log"
+	"regexp"
+	"simplerick/internal/discord"
+	"simplerick/internal/utils"
+	"strconv"
+	"strings"
+)
+
+const (
+	reviewApprovedColor         = 0x2ECC71
+	reviewChangesRequestedColor = 0xE67E22
+	reviewCommentedColor        = 0x95A5A6
+)
+
+var diffHunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+func (h WebhookHandler) handlePullRequestReviewEvent(event *github.PullRequestReviewEvent) error {
+	if *event.Sender.Type == "Bot" {
+		log.Debug().Msg("[GitHub] Ignored pull request review event from bot")
+		return nil
+	}
+
+	if event.GetAction() != "submitted" {
+		return nil
+	}
+
+	review := event.Review
+	pr := event.PullRequest
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling pull request review event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"sender": *event.Sender.Login,
+			"number": pr.GetNumber(),
+			"state":  review.GetState(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetAuthor(review.GetUser().GetLogin(),
+			discord.WithAuthorUrl(review.GetUser().GetHTMLURL()),
+			discord.WithAuthorIcon(review.GetUser().GetAvatarURL())).
+		SetURL(review.GetHTMLURL()).
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	switch strings.ToLower(review.GetState()) {
+	case "approved":
+		builder.
+			SetTitle(fmt.Sprintf("Approved #%d %s", pr.GetNumber(), pr.GetTitle())).
+			SetColor(reviewApprovedColor)
+	case "changes_requested":
+		builder.
+			SetTitle(fmt.Sprintf("Requested changes on #%d %s", pr.GetNumber(), pr.GetTitle())).
+			SetColor(reviewChangesRequestedColor)
+	case "commented":
+		// GitHub wraps every single inline comment in a review without a
+		// body, the comment itself is posted by its own event
+		if len(strings.TrimSpace(review.GetBody())) == 0 {
+			return nil
+		}
+		builder.
+			SetTitle(fmt.Sprintf("Reviewed #%d %s", pr.GetNumber(), pr.GetTitle())).
+			SetColor(reviewCommentedColor)
+	default:
+		return nil
+	}
+
+	if body := strings.TrimSpace(review.GetBody()); len(body) > 0 {
+		builder.SetDescription(utils.EllipsisWords(body, 1024))
+	} else {
+		builder.SetDescription(fmt.Sprintf("on **%s**", *event.Repo.Name))
+	}
+
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, embed)
+}
+
+func (h WebhookHandler) handlePullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent) error {
+	if *event.Sender.Type == "Bot" {
+		log.Debug().Msg("[GitHub] Ignored pull request review comment event from bot")
+		return nil
+	}
+
+	if event.GetAction() != "created" {
+		return nil
+	}
+
+	comment := event.Comment
+	pr := event.PullRequest
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling pull request review comment event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"sender": *event.Sender.Login,
+			"number": pr.GetNumber(),
+			"path":   comment.GetPath(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	location := comment.GetPath()
+	if line, ok := diffHunkLine(comment.GetDiffHunk()); ok {
+		location = fmt.Sprintf("%s:%d", location, line)
+	}
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(fmt.Sprintf("Commented on #%d %s", pr.GetNumber(), pr.GetTitle())).
+		SetURL(comment.GetHTMLURL()).
+		SetColor(reviewCommentedColor).
+		SetAuthor(comment.GetUser().GetLogin(),
+			discord.WithAuthorUrl(comment.GetUser().GetHTMLURL()),
+			discord.WithAuthorIcon(comment.GetUser().GetAvatarURL())).
+		SetDescription(utils.EllipsisWords(comment.GetBody(), 1024)).
+		AddField("File", fmt.Sprintf("`%s`", location))
+
+	if hunk := diffHunkSnippet(comment.GetDiffHunk(), 6); len(hunk) > 0 {
+		builder.AddField("Diff", fmt.Sprintf("```diff\n%s\n```", utils.Ellipsis(hunk, 1000)))
+	}
+
+	builder.
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, embed)
+}
+
+// diffHunkLine returns the line in the new file the diff hunk ends on, which
+// is the line a review comment is attached to.
+func diffHunkLine(hunk string) (int, bool) {
+	lines := strings.Split(hunk, "\n")
+	match := diffHunkHeaderRegex.FindStringSubmatch(lines[0])
+	if match == nil {
+		return 0, false
+	}
+
+	line, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+
+	// Removed lines and "\ No newline at end of file" markers are not part of
+	// the new file
+	line--
+	for _, l := range lines[1:] {
+		if !strings.HasPrefix(l, "-") && !strings.HasPrefix(l, "\\") {
+			line++
+		}
+	}
+
+	return line, true
+}
+
+// diffHunkSnippet returns the last n lines of the diff hunk, leaving out the
+// hunk header.
+func diffHunkSnippet(hunk string, n int) string {
+	lines := strings.Split(hunk, "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "@@") {
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
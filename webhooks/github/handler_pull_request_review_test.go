@@ -0,0 +1,54 @@
+package github
+
+import "testing"
+
+func TestDiffHunkLine(t *testing.T) {
+	tests := []struct {
+		name string
+		hunk string
+		want int
+		ok   bool
+	}{
+		{"header only", "@@ -1,3 +1,3 @@", 0, true},
+		{"context", "@@ -10,3 +10,3 @@\n a\n b", 11, true},
+		{"added", "@@ -10,2 +10,3 @@\n a\n+b", 11, true},
+		{"removed lines skipped", "@@ -10,3 +10,1 @@\n a\n-b\n-c\n d", 11, true},
+		{"ends on removed line", "@@ -10,2 +10,1 @@\n a\n-b", 10, true},
+		{"single line ranges", "@@ -1 +5 @@\n+a", 5, true},
+		{"section heading", "@@ -20,4 +22,5 @@ func main() {\n a\n+b\n c", 24, true},
+		{"no newline marker", "@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file", 1, true},
+		{"empty", "", 0, false},
+		{"no header", " a\n+b", 0, false},
+		{"malformed header", "@@ -a +b @@\n a", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := diffHunkLine(tt.hunk)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("diffHunkLine(%q) = %d, %t, want %d, %t", tt.hunk, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDiffHunkSnippet(t *testing.T) {
+	tests := []struct {
+		name string
+		hunk string
+		n    int
+		want string
+	}{
+		{"drops header", "@@ -1,2 +1,2 @@\n a\n+b", 6, " a\n+b"},
+		{"keeps last lines", "@@ -1,3 +1,3 @@\n a\n b\n+c", 2, " b\n+c"},
+		{"without header", " a\n+b", 1, "+b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffHunkSnippet(tt.hunk, tt.n); got != tt.want {
+				t.Errorf("diffHunkSnippet(%q, %d) = %q, want %q", tt.hunk, tt.n, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"context"
 	"fmt"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/go-github/github"
@@ -8,6 +9,15 @@ import (
 	"simplerick/internal/discord"
 	"simplerick/internal/utils"
 	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	pushColor       = 0x00BCD4
+	forcePushColor  = 0xE67E22
+	newBranchColor  = 0x1ABC9C
+	branchRefPrefix = "refs/heads/"
 )
 
 func (h WebhookHandler) handlePushEvent(event *github.PushEvent) error {
@@ -16,26 +26,42 @@ func (h WebhookHandler) handlePushEvent(event *github.PushEvent) error {
 		return nil
 	}
 
+	// Tags are announced through create and delete events
+	if !strings.HasPrefix(*event.Ref, branchRefPrefix) {
+		return nil
+	}
+
+	// Deleted branches are announced through delete events
+	if event.GetDeleted() {
+		return nil
+	}
+
 	sentry.AddBreadcrumb(&sentry.Breadcrumb{
 		Category: "github",
 		Message:  "Handling push event",
 		Data: map[string]interface{}{
-			"repo":   *event.Repo.Name,
-			"sender": *event.Sender.Login,
-			"ref":    *event.Ref,
+			"repo":    *event.Repo.Name,
+			"sender":  *event.Sender.Login,
+			"ref":     *event.Ref,
+			"forced":  event.GetForced(),
+			"created": event.GetCreated(),
 		},
 		Level: sentry.LevelInfo,
 	})
 
-	branch := (*event.Ref)[len("refs/heads/"):]
+	branch := (*event.Ref)[len(branchRefPrefix):]
+	// Webhook payloads include up to 2048 commits, which is as many as we
+	// ever want to count
 	lenCommits := len(event.Commits)
 
-	if lenCommits == 0 {
+	// A force push can rewind a branch without adding any commits, that is
+	// exactly the kind of push we want to know about.
+	if lenCommits == 0 && !event.GetForced() {
 		return nil
 	}
 
-	builder := discord.NewEmbedBuilder().
-		SetColor(0x00BCD4).
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetColor(pushColor).
 		SetAuthor(*event.Sender.Login,
 			discord.WithAuthorUrl(*event.Sender.HTMLURL),
 			discord.WithAuthorIcon(*event.Sender.AvatarURL)).
@@ -43,36 +69,110 @@ func (h WebhookHandler) handlePushEvent(event *github.PushEvent) error {
 		SetFooter("Simple Rick - GitHub").
 		AddTimestamp()
 
-	if lenCommits == 1 {
+	switch {
+	case event.GetForced():
+		h.describeForcePush(builder, event, branch)
+	case event.GetCreated():
+		builder.
+			SetColor(newBranchColor).
+			SetTitle(fmt.Sprintf("Created branch %s with %s", branch, pluralize(lenCommits, "commit", "commits"))).
+			SetDescription(fmt.Sprintf("on **%s**", *event.Repo.Name)).
+			SetURL(event.GetCompare())
+	case lenCommits == 1:
 		builder.
 			SetTitle("Pushed a commit").
 			SetURL(*event.Commits[0].URL)
-	} else {
+	default:
 		builder.
 			SetTitle(fmt.Sprintf("Pushed %d commits", lenCommits)).
 			SetURL(*event.Compare)
 	}
 
-	// If more than 25 commits, grab last 25
-	if lenCommits > 25 {
-		event.Commits = event.Commits[lenCommits-26 : lenCommits-1]
+	// Only the newest commits are listed, the head commit is always last
+	commits := event.Commits
+	if lenCommits > h.config.PushCommitLimit {
+		commits = commits[lenCommits-h.config.PushCommitLimit:]
 	}
+	omitted := lenCommits - len(commits)
 
-	for _, commit := range event.Commits {
-		sha := (*commit.ID)[:7]
-		messages := strings.Split(*commit.Message, "\n")
+	if omitted > 0 {
+		builder.AddField("Older commits", fmt.Sprintf("and %d more not shown", omitted))
+	}
 
-		title := fmt.Sprintf("`%s` %s", sha, messages[0])
-		description := fmt.Sprintf("- **%s**", *commit.Author.Name)
+	embeds := []discord.Embed{}
+	for _, commit := range commits {
+		name, value := commitField(commit)
 
-		if len(messages) > 1 {
-			description = utils.Ellipsis(strings.Join(messages[1:], "\n"), 255-len(description)) + "\n" + description
+		// Split into another message when this commit would not fit anymore
+		if builder.FieldCount() == discord.MaxEmbedFields || builder.Length()+len(name)+len(value) > discord.MaxEmbedLength {
+			embed, err := builder.Build()
+			if err != nil {
+				return err
+			}
+			embeds = append(embeds, embed)
+			builder = discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+				SetColor(pushColor).
+				SetDescription(fmt.Sprintf("to branch **%s** of **%s** (continued)", branch, *event.Repo.Name)).
+				SetFooter("Simple Rick - GitHub").
+				AddTimestamp()
 		}
 
-		builder.AddField(title, description)
+		builder.AddField(name, value)
 	}
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	embeds = append(embeds, embed)
 
-	h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, builder.Build())
+	for _, embed := range embeds {
+		if err = h.executor.EnqueueEmbed(h.config.ChangelogWebhookUrl, embed); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
+
+func commitField(commit github.PushEventCommit) (string, string) {
+	messages := strings.Split(commit.GetMessage(), "\n")
+
+	name := utils.Ellipsis(fmt.Sprintf("`%s` %s", shortSha(commit.GetID()), messages[0]), discord.MaxEmbedFieldNameLength)
+	value := fmt.Sprintf("- **%s**", commit.GetAuthor().GetName())
+
+	if body := strings.TrimSpace(strings.Join(messages[1:], "\n")); len(body) > 0 {
+		body = utils.EllipsisLines(body, 5)
+		value = utils.EllipsisWords(body, 255-utf8.RuneCountInString(value)) + "\n" + value
+	}
+
+	return name, value
+}
+
+// describeForcePush flags the push as a history rewrite, listing the SHAs
+// before and after and how many commits are no longer on the branch.
+func (h WebhookHandler) describeForcePush(builder *discord.EmbedBuilder, event *github.PushEvent, branch string) {
+	before, after := event.GetBefore(), event.GetAfter()
+
+	builder.
+		SetColor(forcePushColor).
+		SetTitle(fmt.Sprintf("⚠️ Force-pushed to %s", branch)).
+		SetURL(event.GetCompare()).
+		AddField("Before", fmt.Sprintf("`%s`", shortSha(before)), discord.WithFieldInline()).
+		AddField("After", fmt.Sprintf("`%s`", shortSha(after)), discord.WithFieldInline())
+
+	// Stay well within the 10s GitHub waits for the delivery to be answered
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The owner of a push payload only has a legacy name, the login is taken
+	// from the full name instead
+	owner := strings.SplitN(event.Repo.GetFullName(), "/", 2)[0]
+	comparison, _, err := h.client.Repositories.CompareCommits(ctx, owner, event.Repo.GetName(), before, after)
+	if err != nil {
+		log.Warn().Err(err).Msgf("[GitHub] Failed to compare %s...%s of %s", before, after, event.Repo.GetFullName())
+		builder.AddField("Dropped", "unknown", discord.WithFieldInline())
+		return
+	}
+
+	builder.AddField("Dropped", pluralize(comparison.GetBehindBy(), "commit", "commits"), discord.WithFieldInline())
+}
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-github/github"
+	"github.com/rs/zerolog/log"
+	"simplerick/internal/discord"
+	"simplerick/internal/utils"
+	"strings"
+)
+
+const (
+	releaseColor           = 0x2ECC71
+	releasePrereleaseColor = 0xF1C40F
+	releaseDeletedColor    = 0x95A5A6
+)
+
+func (h WebhookHandler) handleReleaseEvent(event *github.ReleaseEvent) error {
+	action := event.GetAction()
+	if action != "published" && action != "prereleased" && action != "edited" && action != "deleted" {
+		return nil
+	}
+
+	release := event.Release
+	if release.GetDraft() {
+		log.Debug().Msg("[GitHub] Ignored release event for draft")
+		return nil
+	}
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "github",
+		Message:  "Handling release event",
+		Data: map[string]interface{}{
+			"repo":   *event.Repo.Name,
+			"sender": *event.Sender.Login,
+			"action": action,
+			"tag":    release.GetTagName(),
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	name := release.GetName()
+	if len(name) == 0 {
+		name = release.GetTagName()
+	}
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetAuthor(*event.Sender.Login,
+			discord.WithAuthorUrl(*event.Sender.HTMLURL),
+			discord.WithAuthorIcon(*event.Sender.AvatarURL)).
+		SetURL(release.GetHTMLURL()).
+		AddField("Tag", fmt.Sprintf("`%s`", release.GetTagName()), discord.WithFieldInline()).
+		AddField("Prerelease", yesNo(release.GetPrerelease()), discord.WithFieldInline()).
+		SetFooter("Simple Rick - GitHub").
+		AddTimestamp()
+
+	switch {
+	case action == "deleted":
+		builder.
+			SetTitle(fmt.Sprintf("Deleted release %s", name)).
+			SetColor(releaseDeletedColor).
+			SetDescription(fmt.Sprintf("Release **%s** of **%s** has been deleted", name, *event.Repo.Name))
+
+		embed, err := builder.Build()
+		if err != nil {
+			return err
+		}
+
+		return h.executor.EnqueueEmbed(h.config.ReleasesWebhookUrl, embed, discord.WithTrackingKey(releaseTrackingKey(event)))
+	case release.GetPrerelease():
+		builder.
+			SetTitle(fmt.Sprintf("Prerelease %s", name)).
+			SetColor(releasePrereleaseColor)
+	default:
+		builder.
+			SetTitle(fmt.Sprintf("Release %s", name)).
+			SetColor(releaseColor)
+	}
+
+	if body := strings.TrimSpace(release.GetBody()); len(body) > 0 {
+		builder.SetDescription(utils.EllipsisWords(body, 2048))
+	} else {
+		builder.SetDescription(fmt.Sprintf("New release of **%s**", *event.Repo.Name))
+	}
+
+	if len(release.Assets) > 0 {
+		builder.AddField(fmt.Sprintf("Assets (%d)", len(release.Assets)), formatReleaseAssets(release.Assets))
+	}
+
+	embed, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.ReleasesWebhookUrl, embed, discord.WithTrackingKey(releaseTrackingKey(event)))
+}
+
+func formatReleaseAssets(assets []github.ReleaseAsset) string {
+	lines := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		lines = append(lines, fmt.Sprintf("[%s](%s) (%s)", asset.GetName(), asset.GetBrowserDownloadURL(), utils.FormatBytes(int64(asset.GetSize()))))
+	}
+	return joinFieldLines(lines)
+}
+
+func releaseTrackingKey(event *github.ReleaseEvent) string {
+	return fmt.Sprintf("github/%s/release/%d", event.Repo.GetFullName(), event.Release.GetID())
+}
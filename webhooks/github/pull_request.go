@@ -0,0 +1,33 @@
+package github
+
+import (
+	"encoding/json"
+	"github.com/google/go-github/github"
+)
+
+// PullRequestEvent adds the draft flag to github.PullRequestEvent, the version
+// of go-github we depend on predates draft pull requests.
+// The Webhook event name is "pull_request".
+type PullRequestEvent struct {
+	*github.PullRequestEvent
+	Draft bool
+}
+
+func parsePullRequestEvent(payload []byte) (*PullRequestEvent, error) {
+	event := &PullRequestEvent{PullRequestEvent: new(github.PullRequestEvent)}
+	if err := json.Unmarshal(payload, event.PullRequestEvent); err != nil {
+		return nil, err
+	}
+
+	var draft struct {
+		PullRequest struct {
+			Draft bool `json:"draft"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(payload, &draft); err != nil {
+		return nil, err
+	}
+	event.Draft = draft.PullRequest.Draft
+
+	return event, nil
+}
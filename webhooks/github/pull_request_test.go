@@ -0,0 +1,31 @@
+package github
+
+import "testing"
+
+func TestParsePullRequestEventDraft(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		draft   bool
+	}{
+		{"draft", `{"action": "opened", "pull_request": {"number": 1, "draft": true}}`, true},
+		{"not draft", `{"action": "opened", "pull_request": {"number": 1, "draft": false}}`, false},
+		{"missing flag", `{"action": "opened", "pull_request": {"number": 1}}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := parseWebHook("pull_request", []byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, ok := event.(*PullRequestEvent)
+			if !ok {
+				t.Fatalf("parseWebHook() = %T, want *PullRequestEvent", event)
+			}
+			if e.Draft != tt.draft || e.GetAction() != "opened" || e.PullRequest.GetNumber() != 1 {
+				t.Errorf("parseWebHook() = draft %t, action %q, number %d, want draft %t", e.Draft, e.GetAction(), e.PullRequest.GetNumber(), tt.draft)
+			}
+		})
+	}
+}
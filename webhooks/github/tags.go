@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"github.com/google/go-github/github"
+	"strings"
+)
+
+type tagInfo struct {
+	sha         string
+	previousTag string
+}
+
+// lookupTag finds the commit the tag points to and the tag preceding it in
+// natural sort order, e.g. v1.10.0 comes after v1.9.2. GitHub lists tags by
+// name rather than by version, so every page has to be searched. When that
+// does not finish within the context, no previous tag is returned rather than
+// a wrong one.
+func (h WebhookHandler) lookupTag(ctx context.Context, repo *github.Repository, tag string) (tagInfo, error) {
+	var info tagInfo
+	owner := repo.GetOwner().GetLogin()
+
+	sha, _, err := h.client.Repositories.GetCommitSHA1(ctx, owner, repo.GetName(), "refs/tags/"+tag, "")
+	if err != nil {
+		return tagInfo{}, err
+	}
+	info.sha = sha
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		tags, res, err := h.client.Repositories.ListTags(ctx, owner, repo.GetName(), opt)
+		if err != nil {
+			info.previousTag = ""
+			return info, err
+		}
+
+		for _, t := range tags {
+			name := t.GetName()
+			if name != tag && compareNatural(name, tag) < 0 && (len(info.previousTag) == 0 || compareNatural(name, info.previousTag) > 0) {
+				info.previousTag = name
+			}
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	return info, nil
+}
+
+// compareNatural compares both strings treating runs of digits as numbers. Like
+// in semantic versioning, a pre-release suffix starting with a dash sorts before
+// the release, e.g. v1.0.0-rc1 comes before v1.0.0.
+func compareNatural(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if isDigit(ra[i]) && isDigit(rb[j]) {
+			si := i
+			for i < len(ra) && isDigit(ra[i]) {
+				i++
+			}
+			sj := j
+			for j < len(rb) && isDigit(rb[j]) {
+				j++
+			}
+
+			// Without leading zeros the longer run is the larger number, runs
+			// of the same length compare like strings
+			na := strings.TrimLeft(string(ra[si:i]), "0")
+			nb := strings.TrimLeft(string(rb[sj:j]), "0")
+			if len(na) != len(nb) {
+				if len(na) < len(nb) {
+					return -1
+				}
+				return 1
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+
+		if ra[i] != rb[j] {
+			if ra[i] < rb[j] {
+				return -1
+			}
+			return 1
+		}
+		i++
+		j++
+	}
+
+	switch {
+	case i == len(ra) && j < len(rb) && rb[j] == '-':
+		return 1
+	case j == len(rb) && i < len(ra) && ra[i] == '-':
+		return -1
+	case len(ra)-i < len(rb)-j:
+		return -1
+	case len(ra)-i > len(rb)-j:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,39 @@
+package github
+
+import "testing"
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"v1.9.2", "v1.10.0", -1},
+		{"v1.10.0", "v1.9.2", 1},
+		{"v2", "v10", -1},
+		{"v1.0", "v1.0.1", -1},
+		{"v1.0.1", "v1.0", 1},
+		// Pre-releases come before their release
+		{"v1.0.0", "v1.0.0-rc1", 1},
+		{"v1.0.0-rc1", "v1.0.0", -1},
+		{"v1.0.0-rc2", "v1.0.0-rc10", -1},
+		{"v1.0.0-rc1", "v1.0.1", -1},
+		{"v1.0.0", "v1.0.0.1", -1},
+		{"a", "b", -1},
+		{"", "v1", -1},
+		{"", "", 0},
+		// Leading zeros make both runs equal, the rest decides
+		{"v01", "v1", 0},
+		{"v007a", "v7b", -1},
+		// Runs too long for an uint64 still compare as numbers
+		{"v99999999999999999999", "v99999999999999999998", 1},
+		{"v99999999999999999999", "v100000000000000000000", -1},
+		{"v1.ä", "v1.a", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareNatural(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareNatural(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package github
+
+import (
+	"encoding/json"
+	"github.com/google/go-github/github"
+)
+
+// WorkflowRun mirrors the workflow_run object of the GitHub API, the version of
+// go-github we depend on predates GitHub Actions.
+type WorkflowRun struct {
+	ID           *int64            `json:"id,omitempty"`
+	Name         *string           `json:"name,omitempty"`
+	HeadBranch   *string           `json:"head_branch,omitempty"`
+	HeadSHA      *string           `json:"head_sha,omitempty"`
+	RunNumber    *int              `json:"run_number,omitempty"`
+	Event        *string           `json:"event,omitempty"`
+	Status       *string           `json:"status,omitempty"`
+	Conclusion   *string           `json:"conclusion,omitempty"`
+	HTMLURL      *string           `json:"html_url,omitempty"`
+	CreatedAt    *github.Timestamp `json:"created_at,omitempty"`
+	UpdatedAt    *github.Timestamp `json:"updated_at,omitempty"`
+	RunStartedAt *github.Timestamp `json:"run_started_at,omitempty"`
+}
+
+// WorkflowRunEvent is triggered when a GitHub Actions workflow run is
+// requested, in progress or completed.
+// The Webhook event name is "workflow_run".
+type WorkflowRunEvent struct {
+	Action      *string              `json:"action,omitempty"`
+	WorkflowRun *WorkflowRun         `json:"workflow_run,omitempty"`
+	Repo        *github.Repository   `json:"repository,omitempty"`
+	Sender      *github.User         `json:"sender,omitempty"`
+	Org         *github.Organization `json:"organization,omitempty"`
+}
+
+// parseWebHook wraps github.ParseWebHook with support for the events that are
+// unknown to go-github or miss fields we need.
+func parseWebHook(messageType string, payload []byte) (interface{}, error) {
+	switch messageType {
+	case "workflow_run":
+		event := new(WorkflowRunEvent)
+		if err := json.Unmarshal(payload, event); err != nil {
+			return nil, err
+		}
+		return event, nil
+	case "pull_request":
+		return parsePullRequestEvent(payload)
+	}
+
+	return github.ParseWebHook(messageType, payload)
+}
+
+func (e *WorkflowRunEvent) GetAction() string {
+	if e == nil || e.Action == nil {
+		return ""
+	}
+	return *e.Action
+}
+
+func (r *WorkflowRun) startedAt() github.Timestamp {
+	if r.RunStartedAt != nil {
+		return *r.RunStartedAt
+	}
+	if r.CreatedAt != nil {
+		return *r.CreatedAt
+	}
+	return github.Timestamp{}
+}
+
+func (r *WorkflowRun) GetName() string {
+	if r == nil || r.Name == nil {
+		return ""
+	}
+	return *r.Name
+}
+
+func (r *WorkflowRun) GetHeadBranch() string {
+	if r == nil || r.HeadBranch == nil {
+		return ""
+	}
+	return *r.HeadBranch
+}
+
+func (r *WorkflowRun) GetHeadSHA() string {
+	if r == nil || r.HeadSHA == nil {
+		return ""
+	}
+	return *r.HeadSHA
+}
+
+func (r *WorkflowRun) GetStatus() string {
+	if r == nil || r.Status == nil {
+		return ""
+	}
+	return *r.Status
+}
+
+func (r *WorkflowRun) GetConclusion() string {
+	if r == nil || r.Conclusion == nil {
+		return ""
+	}
+	return *r.Conclusion
+}
+
+func (r *WorkflowRun) GetHTMLURL() string {
+	if r == nil || r.HTMLURL == nil {
+		return ""
+	}
+	return *r.HTMLURL
+}
+
+func (r *WorkflowRun) GetID() int64 {
+	if r == nil || r.ID == nil {
+		return 0
+	}
+	return *r.ID
+}
@@ -1,26 +1,43 @@
 package sentry
 
 import (
-	"fmt"
+	"errors"
 	"github.com/getsentry/sentry-go"
 	"github.com/rs/zerolog/log"
+	"go.etcd.io/bbolt"
 	"net/http"
 	"simplerick/internal"
 	"simplerick/internal/discord"
 	sentry_api "simplerick/internal/sentry"
-	"time"
 )
 
 type WebhookHandler struct {
-	executor *discord.Executor
-	config   internal.SentryWebhookConfig
+	executor      *discord.Executor
+	config        internal.SentryWebhookConfig
+	history       *issueHistory
+	installations *installationStore
 }
 
-func ProvideWebhookHandler(executor *discord.Executor, config internal.SentryWebhookConfig) WebhookHandler {
-	return WebhookHandler{
-		executor: executor,
-		config:   config,
+func ProvideWebhookHandler(executor *discord.Executor, config internal.SentryWebhookConfig, db *bbolt.DB, trackerConfig internal.TrackerConfig, evictions *internal.Evictions) (WebhookHandler, error) {
+	// The history is listed in the tracked message, so it is kept as long as
+	// the message is tracked
+	history, err := newIssueHistory(db, trackerConfig.TTL)
+	if err != nil {
+		return WebhookHandler{}, err
 	}
+	evictions.Register("Sentry issue histories", history.evict)
+
+	installations, err := newInstallationStore(db)
+	if err != nil {
+		return WebhookHandler{}, err
+	}
+
+	return WebhookHandler{
+		executor:      executor,
+		config:        config,
+		history:       history,
+		installations: installations,
+	}, nil
 }
 
 func (h WebhookHandler) Handler(w http.ResponseWriter, req *http.Request) {
@@ -44,57 +61,38 @@ func (h WebhookHandler) Handler(w http.ResponseWriter, req *http.Request) {
 		},
 	})
 
-	action, event, err := sentry_api.ParseWebhook(sentry_api.WebhookResource(req), payload)
+	webhook, err := sentry_api.ParseWebhookPayload(sentry_api.WebhookResource(req), payload)
 	if err != nil {
 		log.Error().Err(err).Msg("[Sentry] Failed to parse payload")
 		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
-	switch e := event.(type) {
-	case *sentry_api.IssueData:
-		err = h.handleIssue(action, e)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusBadRequest)
-		}
+	if !h.isKnownInstallation(webhook) {
+		log.Warn().
+			Str("installation", webhook.Installation.Uuid).
+			Msg("[Sentry] Rejected payload of unknown installation")
+		w.WriteHeader(http.StatusForbidden)
+		return
 	}
-}
-
-func (h WebhookHandler) handleIssue(action sentry_api.EventAction, data *sentry_api.IssueData) error {
 
-	// TODO: Add support for solving as well
-	if action != sentry_api.IssueCreatedAction && action != sentry_api.IssueResolvedAction {
-		return nil
+	switch e := webhook.Data.(type) {
+	case *sentry_api.InstallationData:
+		err = h.handleInstallation(webhook.Event, webhook.Action, e)
+	case *sentry_api.IssueData:
+		err = h.handleIssue(webhook.Action, webhook.Actor, e)
+	case *sentry_api.MetricAlertData:
+		err = h.handleMetricAlert(webhook.Action, e)
+	case *sentry_api.IssueAlertData:
+		err = h.handleIssueAlert(webhook.Action, e)
 	}
 
-	sentry.AddBreadcrumb(&sentry.Breadcrumb{
-		Category: "sentry",
-		Message:  "Handling issue event",
-		Data: map[string]interface{}{
-			"action": action,
-		},
-		Level: sentry.LevelInfo,
-	})
-
-	builder := discord.NewEmbedBuilder().
-		SetTitle(data.Issue.ShortId).
-		SetURL(fmt.Sprintf("https://sentry.io/organizations/realitymod-dev-team/issues/%s", data.Issue.Id)).
-		SetAuthor(data.Issue.Project.Slug,
-			discord.WithAuthorUrl(fmt.Sprintf("https://sentry.io/organizations/realitymod-dev-team/projects/%s", data.Issue.Project.Slug))).
-		SetDescription(data.Issue.Title).
-		AddField("Status", data.Issue.Status, discord.WithFieldInline()).
-		AddField("Level", data.Issue.Level, discord.WithFieldInline()).
-		AddField("First Seen", data.Issue.FirstSeen.Format(time.RFC3339)).
-		SetFooter("Simple Rick - Sentry").
-		AddTimestamp()
-
-	if action == sentry_api.IssueCreatedAction {
-		builder.SetColor(0xE74C3C) // unsolved
-	} else {
-		builder.SetColor(0x2ECC71) // resolved
+	if errors.Is(err, discord.ErrQueueFull) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("[Sentry] Failed to process payload")
+		w.WriteHeader(http.StatusBadRequest)
 	}
-
-	h.executor.EnqueueEmbed(h.config.IssuesWebhookUrl, builder.Build(), discord.WithTrackingKey(data.Issue.Id))
-
-	return nil
 }
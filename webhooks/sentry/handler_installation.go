@@ -0,0 +1,97 @@
+package sentry
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog/log"
+	"simplerick/internal/discord"
+	sentry_api "simplerick/internal/sentry"
+	"time"
+)
+
+const (
+	installedColor   = 0x2ECC71
+	uninstalledColor = 0x95A5A6
+)
+
+func (h WebhookHandler) handleInstallation(event sentry_api.Event, action sentry_api.EventAction, data *sentry_api.InstallationData) error {
+	inst := installation{
+		Uuid:        data.Installation.Uuid,
+		OrgSlug:     data.Installation.Organization.Slug,
+		InstalledAt: time.Now(),
+	}
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "sentry",
+		Message:  "Handling installation event",
+		Data: map[string]interface{}{
+			"event":        event,
+			"action":       action,
+			"installation": inst.Uuid,
+			"org":          inst.OrgSlug,
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	var title string
+	var color int
+	// Sentry sends uninstalls as a deleted installation, older versions used a
+	// separate uninstallation resource
+	switch action {
+	case sentry_api.InstallationCreatedAction:
+		if err := h.installations.put(inst); err != nil {
+			return fmt.Errorf("failed to record installation: %w", err)
+		}
+		log.Info().Str("installation", inst.Uuid).Msgf("[Sentry] Installed in organization %s", inst.OrgSlug)
+		title, color = "Installed in "+inst.OrgSlug, installedColor
+	case sentry_api.InstallationDeletedAction:
+		if err := h.installations.remove(inst.Uuid); err != nil {
+			return fmt.Errorf("failed to remove installation: %w", err)
+		}
+		log.Info().Str("installation", inst.Uuid).Msgf("[Sentry] Uninstalled from organization %s", inst.OrgSlug)
+		title, color = "Uninstalled from "+inst.OrgSlug, uninstalledColor
+	default:
+		return nil
+	}
+
+	if len(h.config.AdminWebhookUrl) == 0 {
+		return nil
+	}
+
+	embed, err := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(title).
+		SetColor(color).
+		AddField("Organization", inst.OrgSlug, discord.WithFieldInline()).
+		AddField("Installation", inst.Uuid, discord.WithFieldInline()).
+		SetFooter("Simple Rick - Sentry").
+		AddTimestamp().
+		Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.AdminWebhookUrl, embed)
+}
+
+// isKnownInstallation reports whether the payload comes from a recorded or
+// configured installation. Always true unless the config requires known
+// installations, and for the installation events that record them.
+func (h WebhookHandler) isKnownInstallation(webhook *sentry_api.WebhookPayload) bool {
+	if !h.config.RequireInstallation ||
+		webhook.Event == sentry_api.InstallationEvent || webhook.Event == sentry_api.UninstallationEvent {
+		return true
+	}
+
+	for _, uuid := range h.config.Installations {
+		if uuid == webhook.Installation.Uuid {
+			return true
+		}
+	}
+
+	inst, err := h.installations.get(webhook.Installation.Uuid)
+	if err != nil {
+		log.Error().Err(err).Msg("[Sentry] Failed to look up installation")
+		return false
+	}
+	return inst != nil
+}
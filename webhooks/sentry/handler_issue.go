@@ -0,0 +1,185 @@
+package sentry
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog/log"
+	"simplerick/internal/discord"
+	sentry_api "simplerick/internal/sentry"
+	"strings"
+	"time"
+)
+
+const (
+	issueUnresolvedColor = 0xE74C3C
+	issueRegressedColor  = 0x992D22
+	issueEscalatingColor = 0xE67E22
+	issueResolvedColor   = 0x2ECC71
+	issueIgnoredColor    = 0x95A5A6
+)
+
+func (h WebhookHandler) handleIssue(action sentry_api.EventAction, actor sentry_api.Actor, data *sentry_api.IssueData) error {
+	switch action {
+	case sentry_api.IssueCreatedAction, sentry_api.IssueResolvedAction, sentry_api.IssueAssignedAction,
+		sentry_api.IssueIgnoredAction, sentry_api.IssueArchivedAction, sentry_api.IssueUnresolvedAction:
+	default:
+		return nil
+	}
+
+	issue := data.Issue
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "sentry",
+		Message:  "Handling issue event",
+		Data: map[string]interface{}{
+			"action": action,
+			"issue":  issue.Id,
+			"status": issue.Status,
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	// Sentry itself also unresolves an issue when an ignore condition expires,
+	// so only guess that it regressed for older versions without a substatus
+	regressed := issue.Substatus == sentry_api.IssueSubstatusRegressed ||
+		(len(issue.Substatus) == 0 && action == sentry_api.IssueUnresolvedAction && actor.Type == "application")
+
+	var status string
+	var color int
+	switch {
+	case issue.Status == sentry_api.IssueStatusResolved:
+		status, color = joinNonEmpty("Resolved", resolvedDetails(issue.StatusDetails)), issueResolvedColor
+	case issue.Status == sentry_api.IssueStatusIgnored:
+		status, color = joinNonEmpty("Ignored", ignoredDetails(issue.StatusDetails)), issueIgnoredColor
+	case regressed:
+		status, color = "Regressed", issueRegressedColor
+	case issue.Substatus == sentry_api.IssueSubstatusEscalating:
+		status, color = "Escalating", issueEscalatingColor
+	default:
+		status, color = "Unresolved", issueUnresolvedColor
+	}
+
+	line := fmt.Sprintf("<t:%d:f> %s", time.Now().Unix(), historyLine(action, actor, issue.AssignedTo, status))
+	history, err := h.history.append(issue.Id, line)
+	if err != nil {
+		log.Error().Err(err).Str("issue", issue.Id).Msg("[Sentry] Failed to update issue history")
+		history = []string{line}
+	}
+
+	issueUrl, projectUrl := h.issueLinks(issue.Id, issue.WebUrl, issue.ProjectUrl, issue.Project.Slug)
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(issue.ShortId).
+		SetURL(issueUrl).
+		SetAuthor(issue.Project.Slug, discord.WithAuthorUrl(projectUrl)).
+		SetDescription(issue.Title).
+		SetColor(color).
+		AddField("Status", status, discord.WithFieldInline()).
+		AddField("Level", issue.Level, discord.WithFieldInline())
+
+	if issue.AssignedTo != nil {
+		builder.AddField("Assignee", assigneeName(issue.AssignedTo), discord.WithFieldInline())
+	}
+
+	embed, err := builder.
+		AddField("First Seen", issue.FirstSeen.Format(time.RFC3339)).
+		AddField("History", strings.Join(history, "\n")).
+		SetFooter("Simple Rick - Sentry").
+		AddTimestamp().
+		Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.IssuesWebhookUrl, embed, discord.WithTrackingKey(issue.Id))
+}
+
+func historyLine(action sentry_api.EventAction, actor sentry_api.Actor, assignee *sentry_api.Assignee, status string) string {
+	var line string
+	switch action {
+	case sentry_api.IssueCreatedAction:
+		return "Created"
+	case sentry_api.IssueAssignedAction:
+		if assignee == nil {
+			line = "Unassigned"
+		} else {
+			line = "Assigned to " + assigneeName(assignee)
+		}
+	default:
+		line = status
+	}
+
+	if actor.Type == "user" && len(actor.Name) > 0 {
+		line += " by " + actor.Name
+	}
+	return line
+}
+
+func assigneeName(assignee *sentry_api.Assignee) string {
+	if assignee.Type == "team" {
+		return "#" + assignee.Name
+	}
+	return assignee.Name
+}
+
+func resolvedDetails(details sentry_api.StatusDetails) string {
+	switch {
+	case details.InNextRelease:
+		return "in the next release"
+	case len(details.InRelease) > 0:
+		return "in release " + details.InRelease
+	case details.InCommit != nil:
+		return "in a commit"
+	}
+	return ""
+}
+
+func ignoredDetails(details sentry_api.StatusDetails) string {
+	switch {
+	case details.IgnoreUntilEscalating:
+		return "until escalating"
+	case details.IgnoreUntil != nil:
+		return fmt.Sprintf("until <t:%d:f>", details.IgnoreUntil.Unix())
+	case details.IgnoreDuration > 0:
+		return "for " + formatMinutes(details.IgnoreDuration)
+	case details.IgnoreCount > 0:
+		return joinNonEmpty(fmt.Sprintf("until it occurs %d more times", details.IgnoreCount), window(details.IgnoreWindow))
+	case details.IgnoreUserCount > 0:
+		return joinNonEmpty(fmt.Sprintf("until it affects %d more users", details.IgnoreUserCount), window(details.IgnoreUserWindow))
+	}
+	return "forever"
+}
+
+func window(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	return "within " + formatMinutes(minutes)
+}
+
+func formatMinutes(minutes int) string {
+	switch {
+	case minutes%(24*60) == 0:
+		return pluralize(minutes/(24*60), "day")
+	case minutes%60 == 0:
+		return pluralize(minutes/60, "hour")
+	}
+	return pluralize(minutes, "minute")
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+func joinNonEmpty(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if len(part) > 0 {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
@@ -0,0 +1,106 @@
+package sentry
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"simplerick/internal/discord"
+	sentry_api "simplerick/internal/sentry"
+	"simplerick/internal/utils"
+	"strings"
+)
+
+// maxStackFrames is how many of the innermost in-app frames are previewed.
+const maxStackFrames = 5
+
+var levelColors = map[string]int{
+	"fatal":   0x992D22,
+	"error":   0xE74C3C,
+	"warning": 0xE67E22,
+	"info":    0x3498DB,
+	"debug":   0x95A5A6,
+}
+
+func (h WebhookHandler) handleIssueAlert(action sentry_api.EventAction, data *sentry_api.IssueAlertData) error {
+	if action != sentry_api.IssueAlertTriggeredAction {
+		return nil
+	}
+
+	event := data.Event
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "sentry",
+		Message:  "Handling issue alert event",
+		Data: map[string]interface{}{
+			"action": action,
+			"event":  event.EventId,
+			"rule":   data.TriggeredRule,
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	color, ok := levelColors[event.Level]
+	if !ok {
+		color = levelColors["error"]
+	}
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(event.Title).
+		SetURL(event.WebUrl).
+		SetColor(color).
+		AddField("Rule", data.TriggeredRule, discord.WithFieldInline()).
+		AddField("Level", event.Level, discord.WithFieldInline())
+
+	// The last exception is the one that was raised, the ones before caused it
+	if values := event.Exception.Values; len(values) > 0 {
+		exception := values[len(values)-1]
+		builder.SetDescription(fmt.Sprintf("**%s**: %s", exception.Type, exception.Value))
+
+		var lines []string
+		frames := exception.Stacktrace.Frames
+		for i := len(frames) - 1; i >= 0 && len(lines) < maxStackFrames; i-- {
+			frame := frames[i]
+			if !frame.InApp {
+				continue
+			}
+
+			function := "<anonymous>"
+			if frame.Function != nil {
+				function = *frame.Function
+			}
+			file := frame.Filename
+			if len(file) == 0 {
+				file = frame.AbsPath
+			}
+
+			lines = append(lines, fmt.Sprintf("at %s (%s:%d)", function, file, frame.Lineno))
+			if frame.ContextLine != nil {
+				lines = append(lines, "    "+utils.Ellipsis(strings.TrimSpace(*frame.ContextLine), 80))
+			}
+		}
+		if len(lines) > 0 {
+			builder.AddField("Stack Trace", fmt.Sprintf("```\n%s\n```", utils.Ellipsis(strings.Join(lines, "\n"), discord.MaxEmbedFieldValueLength-8)))
+		}
+	} else if len(event.Message) > 0 {
+		builder.SetDescription(event.Message)
+	}
+
+	if browser := event.Contexts.Browser; len(browser.Name) > 0 {
+		builder.AddField("Browser", strings.TrimSpace(browser.Name+" "+browser.Version), discord.WithFieldInline())
+	}
+	if system := event.Contexts.Os; len(system.Name) > 0 {
+		builder.AddField("OS", strings.TrimSpace(system.Name+" "+system.Version), discord.WithFieldInline())
+	}
+	if len(event.Request.Url) > 0 {
+		builder.AddField("URL", event.Request.Url)
+	}
+
+	embed, err := builder.
+		SetFooter("Simple Rick - Sentry").
+		AddTimestamp().
+		Build()
+	if err != nil {
+		return err
+	}
+
+	return h.executor.EnqueueEmbed(h.config.EventAlertsWebhookUrl, embed)
+}
@@ -0,0 +1,115 @@
+package sentry
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"simplerick/internal/discord"
+	sentry_api "simplerick/internal/sentry"
+	"strconv"
+	"strings"
+)
+
+const (
+	metricAlertCriticalColor = 0xE74C3C
+	metricAlertWarningColor  = 0xE67E22
+	metricAlertResolvedColor = 0x2ECC71
+)
+
+func (h WebhookHandler) handleMetricAlert(action sentry_api.EventAction, data *sentry_api.MetricAlertData) error {
+	var color int
+	switch action {
+	case sentry_api.MetricAlertCriticalAction:
+		color = metricAlertCriticalColor
+	case sentry_api.MetricAlertWarningAction:
+		color = metricAlertWarningColor
+	case sentry_api.MetricAlertResolvedAction:
+		color = metricAlertResolvedColor
+	default:
+		return nil
+	}
+
+	incident := data.MetricAlert
+	rule := incident.AlertRule
+
+	sentry.AddBreadcrumb(&sentry.Breadcrumb{
+		Category: "sentry",
+		Message:  "Handling metric alert event",
+		Data: map[string]interface{}{
+			"action":   action,
+			"incident": incident.Id,
+			"rule":     rule.Name,
+		},
+		Level: sentry.LevelInfo,
+	})
+
+	title := data.DescriptionTitle
+	if len(title) == 0 {
+		title = rule.Name
+	}
+
+	builder := discord.NewEmbedBuilder(discord.WithAutoTruncate()).
+		SetTitle(title).
+		SetURL(data.WebUrl).
+		SetDescription(data.DescriptionText).
+		SetColor(color).
+		AddField("Status", strings.Title(string(action)), discord.WithFieldInline()).
+		AddField("Rule", rule.Name, discord.WithFieldInline())
+
+	if threshold := metricAlertThreshold(action, rule.Triggers, rule.ResolveThreshold); len(threshold) > 0 {
+		builder.AddField("Threshold", threshold, discord.WithFieldInline())
+	}
+	if len(rule.Aggregate) > 0 {
+		builder.AddField("Metric", fmt.Sprintf("`%s` over %d minutes", rule.Aggregate, rule.TimeWindow), discord.WithFieldInline())
+	}
+	if len(incident.Projects) > 0 {
+		builder.AddField("Projects", strings.Join(incident.Projects, ", "), discord.WithFieldInline())
+	}
+
+	embed, err := builder.
+		SetFooter("Simple Rick - Sentry").
+		AddTimestamp().
+		Build()
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("sentry/%s/metric-alert/%s", incident.OrganizationId, incident.Id)
+	return h.executor.EnqueueEmbed(h.config.AlertsWebhookUrl, embed, discord.WithTrackingKey(key))
+}
+
+// metricAlertThreshold describes the threshold of the trigger that fired, or
+// the one the incident resolved at. Empty when the payload does not say.
+func metricAlertThreshold(action sentry_api.EventAction, triggers []sentry_api.MetricAlertTrigger, resolveThreshold *float64) string {
+	for _, trigger := range triggers {
+		if action != sentry_api.MetricAlertResolvedAction {
+			if trigger.Label == string(action) {
+				return thresholdText(trigger.ThresholdType, trigger.AlertThreshold)
+			}
+			continue
+		}
+
+		// Resolving happens on the opposite side of the threshold
+		if trigger.ResolveThreshold != nil {
+			resolveThreshold = trigger.ResolveThreshold
+		}
+		if resolveThreshold != nil {
+			return thresholdText(invertThresholdType(trigger.ThresholdType), *resolveThreshold)
+		}
+	}
+	return ""
+}
+
+func thresholdText(thresholdType int, threshold float64) string {
+	value := strconv.FormatFloat(threshold, 'f', -1, 64)
+	if thresholdType == sentry_api.ThresholdTypeBelow {
+		return "Below " + value
+	}
+	return "Above " + value
+}
+
+func invertThresholdType(thresholdType int) int {
+	if thresholdType == sentry_api.ThresholdTypeBelow {
+		return sentry_api.ThresholdTypeAbove
+	}
+	return sentry_api.ThresholdTypeBelow
+}
@@ -0,0 +1,66 @@
+package sentry
+
+import (
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+var installationBucket = []byte("sentry_installations")
+
+type installation struct {
+	Uuid        string    `json:"uuid"`
+	OrgSlug     string    `json:"org_slug"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// installationStore records the organizations the integration is installed
+// in, keyed by installation uuid.
+type installationStore struct {
+	db *bbolt.DB
+}
+
+func newInstallationStore(db *bbolt.DB) (*installationStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(installationBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &installationStore{db}, nil
+}
+
+func (s *installationStore) put(inst installation) error {
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(installationBucket).Put([]byte(inst.Uuid), data)
+	})
+}
+
+// get returns the installation with the uuid, or nil when it is unknown.
+func (s *installationStore) get(uuid string) (*installation, error) {
+	var inst *installation
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(installationBucket).Get([]byte(uuid))
+		if data == nil {
+			return nil
+		}
+		inst = new(installation)
+		return json.Unmarshal(data, inst)
+	})
+
+	return inst, err
+}
+
+func (s *installationStore) remove(uuid string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(installationBucket).Delete([]byte(uuid))
+	})
+}
@@ -0,0 +1,105 @@
+package sentry
+
+import (
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+// maxHistoryLines is how many of the latest transitions of an issue are kept.
+const maxHistoryLines = 5
+
+var issueHistoryBucket = []byte("sentry_issue_history")
+
+type issueHistoryEntry struct {
+	Lines     []string  `json:"lines"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// issueHistory keeps the latest transitions of every issue, so the tracked
+// message can list them when it is edited. Histories are forgotten after the
+// ttl, like the tracked message they belong to.
+type issueHistory struct {
+	db  *bbolt.DB
+	ttl time.Duration
+}
+
+func newIssueHistory(db *bbolt.DB, ttl time.Duration) (*issueHistory, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(issueHistoryBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &issueHistory{db, ttl}, nil
+}
+
+// append adds the line to the history of the issue, returning the history
+// including it.
+func (h *issueHistory) append(issueId string, line string) ([]string, error) {
+	var entry issueHistoryEntry
+
+	err := h.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(issueHistoryBucket)
+
+		// An unreadable or expired history is started over
+		if data := bucket.Get([]byte(issueId)); data != nil {
+			if err := json.Unmarshal(data, &entry); err != nil || h.expired(entry) {
+				entry = issueHistoryEntry{}
+			}
+		}
+
+		entry.Lines = append(entry.Lines, line)
+		if len(entry.Lines) > maxHistoryLines {
+			entry.Lines = entry.Lines[len(entry.Lines)-maxHistoryLines:]
+		}
+		entry.UpdatedAt = time.Now()
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(issueId), data)
+	})
+
+	return entry.Lines, err
+}
+
+// evict removes the histories that were not updated within the ttl, returning
+// how many were removed.
+func (h *issueHistory) evict() (int, error) {
+	var stale [][]byte
+
+	err := h.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(issueHistoryBucket)
+
+		err := bucket.ForEach(func(k, v []byte) error {
+			var entry issueHistoryEntry
+			if err := json.Unmarshal(v, &entry); err != nil || h.expired(entry) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range stale {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(stale), nil
+}
+
+func (h *issueHistory) expired(entry issueHistoryEntry) bool {
+	return h.ttl > 0 && time.Since(entry.UpdatedAt) > h.ttl
+}
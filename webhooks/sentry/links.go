@@ -0,0 +1,34 @@
+package sentry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// issueLinks returns the links to the issue and its project. They are taken
+// from the payload when Sentry includes them, so every organization and
+// self-hosted instance links to the right place, and built from the configured
+// base url and organization otherwise.
+func (h WebhookHandler) issueLinks(issueId string, webUrl string, projectUrl string, projectSlug string) (string, string) {
+	issueUrl := webUrl
+	if len(issueUrl) == 0 && len(h.config.OrgSlug) > 0 {
+		issueUrl = fmt.Sprintf("%s/organizations/%s/issues/%s/", h.config.BaseUrl, h.config.OrgSlug, issueId)
+	}
+
+	if len(projectUrl) == 0 {
+		projectUrl = projectLink(issueUrl, projectSlug)
+	}
+
+	return issueUrl, projectUrl
+}
+
+// projectLink derives the link to the project from the link to one of its
+// issues, which works for both https://sentry.io/organizations/<org>/issues/<id>/
+// and https://<org>.sentry.io/issues/<id>/ links.
+func projectLink(issueUrl string, projectSlug string) string {
+	i := strings.LastIndex(issueUrl, "/issues/")
+	if i < 0 || len(projectSlug) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/projects/%s/", issueUrl[:i], projectSlug)
+}
@@ -9,11 +9,11 @@ import (
 	"simplerick/webhooks"
 )
 
-func setupApplication(ctx context.Context) (application, error) {
+func setupApplication(ctx context.Context) (application, func(), error) {
 	wire.Build(
 		internal.Set,
 		webhooks.Set,
 		applicationSet,
 	)
-	return application{}, nil
+	return application{}, nil, nil
 }
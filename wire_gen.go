@@ -7,6 +7,7 @@ package main
 
 import (
 	"context"
+	"simplerick/admin"
 	"simplerick/internal"
 	"simplerick/internal/discord"
 	"simplerick/webhooks/github"
@@ -15,19 +16,71 @@ import (
 
 // Injectors from wire.go:
 
-func setupApplication(ctx context.Context) (application, error) {
-	executor := discord.ProvideExecutor()
+func setupApplication(ctx context.Context) (application, func(), error) {
+	databaseConfig := internal.ProvideDatabaseConfig()
+	db, cleanup, err := internal.ProvideDatabase(databaseConfig)
+	if err != nil {
+		return application{}, nil, err
+	}
+	trackerConfig, err := internal.ProvideTrackerConfig()
+	if err != nil {
+		cleanup()
+		return application{}, nil, err
+	}
+	evictions := internal.ProvideEvictions()
+	tracker, cleanup2, err := internal.ProvideTracker(trackerConfig, db, evictions)
+	if err != nil {
+		cleanup()
+		return application{}, nil, err
+	}
+	queueConfig, err := internal.ProvideQueueConfig()
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return application{}, nil, err
+	}
+	executor, err := discord.ProvideExecutor(db, tracker, queueConfig)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return application{}, nil, err
+	}
 	githubWebhookConfig, err := internal.ProvideGithubWebhookConfig()
 	if err != nil {
-		return application{}, err
+		cleanup2()
+		cleanup()
+		return application{}, nil, err
 	}
 	webhookHandler := github.ProvideWebhookHandler(executor, githubWebhookConfig)
 	sentryWebhookConfig, err := internal.ProvideSentryWebhookConfig()
 	if err != nil {
-		return application{}, err
+		cleanup2()
+		cleanup()
+		return application{}, nil, err
+	}
+	sentryWebhookHandler, err := sentry.ProvideWebhookHandler(executor, sentryWebhookConfig, db, trackerConfig, evictions)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return application{}, nil, err
+	}
+	adminConfig := internal.ProvideAdminConfig()
+	handler := admin.ProvideHandler(executor, tracker, adminConfig)
+	router := newRouter(webhookHandler, sentryWebhookHandler, handler)
+	serverConfig, err := internal.ProvideServerConfig()
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return application{}, nil, err
+	}
+	mainApplication, err := newApplication(router, executor, serverConfig)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return application{}, nil, err
 	}
-	sentryWebhookHandler := sentry.ProvideWebhookHandler(executor, sentryWebhookConfig)
-	router := newRouter(webhookHandler, sentryWebhookHandler)
-	mainApplication := newApplication(router)
-	return mainApplication, nil
+	return mainApplication, func() {
+		cleanup2()
+		cleanup()
+	}, nil
 }